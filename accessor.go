@@ -0,0 +1,368 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/kayon/memscan/deck"
+)
+
+// MemoryAccessor 访问目标的内存和 Region 列表
+// ReadV/WriteV 与 process_vm_readv/writev 的语义相同: 按顺序处理 remote, 遇到无法访问的地址时停止,
+// 返回已传输的字节数; 一个字节都没有传输时返回错误 (EFAULT, EPERM, ESRCH)
+type MemoryAccessor interface {
+	ReadV(local []unix.Iovec, remote []unix.RemoteIovec) (int, error)
+	WriteV(local []unix.Iovec, remote []unix.RemoteIovec) (int, error)
+	// OpenMaps /proc/pid/maps 格式的 Region 列表, 每次 Seek 到开头时反映当前的映射
+	OpenMaps() (io.ReadSeekCloser, error)
+	// Exe 可执行文件的路径, 用于识别主程序的 Region
+	Exe() string
+	Alive() bool
+}
+
+var (
+	_ MemoryAccessor = ProcessVM{}
+	_ MemoryAccessor = (*ProcMem)(nil)
+	_ MemoryAccessor = (*FakeMemory)(nil)
+)
+
+// procTarget 通过 /proc/pid 获取 Region 列表
+type procTarget struct {
+	pid int
+}
+
+func (p procTarget) PID() int {
+	return p.pid
+}
+
+func (p procTarget) OpenMaps() (io.ReadSeekCloser, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/maps", p.pid))
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p procTarget) Exe() string {
+	exePath, _ := os.Readlink(fmt.Sprintf("/proc/%d/exe", p.pid))
+	return exePath
+}
+
+func (p procTarget) Alive() bool {
+	return deck.ProcessExists(p.pid)
+}
+
+// ProcessVM 使用 process_vm_readv/writev, Open 默认使用的方式
+// 遵守页面权限, 无法写入只读页面
+type ProcessVM struct {
+	procTarget
+}
+
+func NewProcessVM(pid int) ProcessVM {
+	return ProcessVM{procTarget{pid: pid}}
+}
+
+func (p ProcessVM) ReadV(local []unix.Iovec, remote []unix.RemoteIovec) (int, error) {
+	n, err := unix.ProcessVMReadv(p.pid, local, remote, 0)
+	return max(n, 0), err
+}
+
+func (p ProcessVM) WriteV(local []unix.Iovec, remote []unix.RemoteIovec) (int, error) {
+	n, err := unix.ProcessVMWritev(p.pid, local, remote, 0)
+	return max(n, 0), err
+}
+
+// ProcMem 使用 /proc/pid/mem, 需要与 process_vm_* 相同的 ptrace 权限
+// 每个 iovec 一次 pread/pwrite, 比 ProcessVM 慢, 但可以写入只读页面 (例如代码段)
+// 不再使用时需要 Close
+type ProcMem struct {
+	procTarget
+	file *os.File
+}
+
+func NewProcMem(pid int) (*ProcMem, error) {
+	f, err := os.OpenFile(fmt.Sprintf("/proc/%d/mem", pid), os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &ProcMem{procTarget: procTarget{pid: pid}, file: f}, nil
+}
+
+func (p *ProcMem) Close() error {
+	return p.file.Close()
+}
+
+func (p *ProcMem) ReadV(local []unix.Iovec, remote []unix.RemoteIovec) (int, error) {
+	return transferV(local, remote, func(buf []byte, addr uint64) (int, error) {
+		return procMemResult(unix.Pread(int(p.file.Fd()), buf, int64(addr)))
+	})
+}
+
+func (p *ProcMem) WriteV(local []unix.Iovec, remote []unix.RemoteIovec) (int, error) {
+	return transferV(local, remote, func(buf []byte, addr uint64) (int, error) {
+		return procMemResult(unix.Pwrite(int(p.file.Fd()), buf, int64(addr)))
+	})
+}
+
+// procMemResult 转换为 process_vm_* 的错误
+// 无法访问的地址返回 EIO, 进程退出后返回 0
+func procMemResult(n int, err error) (int, error) {
+	n = max(n, 0)
+	if errors.Is(err, unix.EIO) {
+		err = unix.EFAULT
+	} else if n == 0 && err == nil {
+		err = unix.ESRCH
+	}
+	return n, err
+}
+
+// transferV 按 process_vm_readv/writev 的语义依次处理 remote
+// fn 传输一段本地和远程都连续的数据, 返回的字节数小于 len(buf) 时停止
+func transferV(local []unix.Iovec, remote []unix.RemoteIovec, fn func(buf []byte, addr uint64) (int, error)) (int, error) {
+	var total, li int
+	var loff uint64
+	for _, r := range remote {
+		addr := uint64(r.Base)
+		left := r.Len
+		for left > 0 {
+			for li < len(local) && loff >= local[li].Len {
+				li++
+				loff = 0
+			}
+			if li == len(local) {
+				return total, nil
+			}
+			buf := unsafe.Slice(local[li].Base, local[li].Len)[loff:]
+			if len(buf) > left {
+				buf = buf[:left]
+			}
+			n, err := fn(buf, addr)
+			total += n
+			if n < len(buf) {
+				if total > 0 {
+					return total, nil
+				}
+				if err == nil {
+					err = unix.EFAULT
+				}
+				return 0, err
+			}
+			addr += uint64(n)
+			left -= n
+			loff += uint64(n)
+		}
+	}
+	return total, nil
+}
+
+// FakeMemory 由合成 Region 组成的内存, 不需要真实的进程, 用于测试
+// 与 process_vm_* 一样遵守页面权限, 未映射或没有权限的地址返回 EFAULT
+type FakeMemory struct {
+	mu sync.RWMutex
+	// regions 按 Start 排列, 互不重叠
+	regions []fakeRegion
+	exe     string
+	dead    bool
+	// written ClearDirty 之后通过 WriteV 写入或者新映射的页面
+	// 从未 ClearDirty 时为 nil, 所有页面都视为写入过
+	written map[uint64]struct{}
+}
+
+type fakeRegion struct {
+	Region
+	data []byte
+}
+
+// NewFakeMemory exe 为主程序的路径, 文件名等于 exe 的 Region 被识别为 REGION_TYPE_EXE
+func NewFakeMemory(exe string) *FakeMemory {
+	return &FakeMemory{exe: exe}
+}
+
+// Map 将 data 映射到 [start, start+len(data)), data 不会被复制
+// 通常 start 和 len(data) 按页对齐, filename 为空时是匿名映射
+func (f *FakeMemory) Map(start uint64, data []byte, perm Permissions, filename string) error {
+	if len(data) == 0 {
+		return errors.New("FakeMemory.Map: empty region")
+	}
+	end := start + uint64(len(data))
+	if end < start {
+		return errors.New("FakeMemory.Map: address overflow")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i, _ := slices.BinarySearchFunc(f.regions, start, func(r fakeRegion, addr uint64) int {
+		return cmp.Compare(r.Start, addr)
+	})
+	if (i > 0 && f.regions[i-1].End > start) || (i < len(f.regions) && f.regions[i].Start < end) {
+		return fmt.Errorf("FakeMemory.Map: %08X-%08X overlaps an existing region", start, end)
+	}
+	f.regions = slices.Insert(f.regions, i, fakeRegion{
+		Region: Region{Start: start, End: end, Size: end - start, Perm: perm, Filename: filename},
+		data:   data,
+	})
+	f.markWritten(start, end)
+	return nil
+}
+
+// Unmap 删除从 start 开始的 Region, 之后访问它返回 EFAULT
+func (f *FakeMemory) Unmap(start uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, r := range f.regions {
+		if r.Start == start {
+			f.regions = slices.Delete(f.regions, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// Kill 模拟进程退出, 之后所有访问返回 ESRCH
+func (f *FakeMemory) Kill() {
+	f.mu.Lock()
+	f.dead = true
+	f.regions = nil
+	f.mu.Unlock()
+}
+
+func (f *FakeMemory) ReadV(local []unix.Iovec, remote []unix.RemoteIovec) (int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.dead {
+		return 0, unix.ESRCH
+	}
+	return transferV(local, remote, func(buf []byte, addr uint64) (int, error) {
+		return f.access(buf, addr, false), nil
+	})
+}
+
+func (f *FakeMemory) WriteV(local []unix.Iovec, remote []unix.RemoteIovec) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.dead {
+		return 0, unix.ESRCH
+	}
+	return transferV(local, remote, func(buf []byte, addr uint64) (int, error) {
+		n := f.access(buf, addr, true)
+		f.markWritten(addr, addr+uint64(n))
+		return n, nil
+	})
+}
+
+// access 从 addr 开始读取或写入 buf, 可以跨越相邻的 Region, 返回传输的字节数
+func (f *FakeMemory) access(buf []byte, addr uint64, write bool) (n int) {
+	for n < len(buf) {
+		data := f.find(addr+uint64(n), write)
+		if data == nil {
+			break
+		}
+		if write {
+			n += copy(data, buf[n:])
+		} else {
+			n += copy(buf[n:], data)
+		}
+	}
+	return
+}
+
+// find addr 所在 Region 从 addr 开始的数据, 不可访问时返回 nil
+func (f *FakeMemory) find(addr uint64, write bool) []byte {
+	i, found := slices.BinarySearchFunc(f.regions, addr, func(r fakeRegion, addr uint64) int {
+		return cmp.Compare(r.Start, addr)
+	})
+	if !found {
+		i--
+	}
+	if i < 0 || addr >= f.regions[i].End {
+		return nil
+	}
+	r := f.regions[i]
+	if (write && !r.Perm.Write()) || (!write && !r.Perm.Read()) {
+		return nil
+	}
+	return r.data[addr-r.Start:]
+}
+
+func (f *FakeMemory) OpenMaps() (io.ReadSeekCloser, error) {
+	f.mu.RLock()
+	dead := f.dead
+	f.mu.RUnlock()
+	if dead {
+		return nil, unix.ESRCH
+	}
+	maps := &fakeMaps{mem: f}
+	maps.r.Reset(f.render())
+	return maps, nil
+}
+
+func (f *FakeMemory) Exe() string {
+	return f.exe
+}
+
+func (f *FakeMemory) Alive() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return !f.dead
+}
+
+// render 生成 /proc/pid/maps 格式的文本
+func (f *FakeMemory) render() []byte {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var buf bytes.Buffer
+	for _, r := range f.regions {
+		_, _ = fmt.Fprintf(&buf, "%x-%x %s 00000000 00:00 0 %s\n", r.Start, r.End, r.Perm, r.Filename)
+	}
+	return buf.Bytes()
+}
+
+// fakeMaps 每次 Seek 到开头时重新生成, 与 /proc/pid/maps 一样反映当前的映射
+type fakeMaps struct {
+	mem *FakeMemory
+	r   bytes.Reader
+}
+
+func (m *fakeMaps) Read(p []byte) (int, error) {
+	return m.r.Read(p)
+}
+
+func (m *fakeMaps) Seek(offset int64, whence int) (int64, error) {
+	if offset == 0 && whence == io.SeekStart {
+		m.r.Reset(m.mem.render())
+	}
+	return m.r.Seek(offset, whence)
+}
+
+func (m *fakeMaps) Close() error {
+	return nil
+}
@@ -0,0 +1,110 @@
+package memscan
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"runtime"
+	"slices"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+func TestFakeMemory_Scan(t *testing.T) {
+	mem := NewFakeMemory("/fake/game")
+	heap := make([]byte, 2*memPageSize)
+	exe := make([]byte, memPageSize)
+	readOnly := make([]byte, memPageSize)
+	put := func(b []byte, offset int, v uint32) {
+		binary.LittleEndian.PutUint32(b[offset:], v)
+	}
+	put(heap, 0x10, 1234)
+	put(heap, 0x1040, 1234)
+	put(exe, 0x8, 1234)
+	put(readOnly, 0, 1234)
+
+	rw := ParsePermissions("rw-p")
+	for _, err := range []error{
+		mem.Map(0x10000, heap, rw, ""),
+		mem.Map(0x20000, exe, rw, "/fake/game"),
+		mem.Map(0x30000, readOnly, ParsePermissions("r--p"), ""),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mem.Map(0x10000+memPageSize, exe, rw, ""); err == nil {
+		t.Fatal("overlapping region mapped")
+	}
+
+	m := NewMemscan()
+	if err := m.OpenAccessor(mem, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	report := m.FirstScan(scanner.NewInt32(1234))
+	if report.Err != nil || report.FailedRegions != 0 {
+		t.Fatalf("first scan: %+v", report)
+	}
+	want := []uint64{0x10010, 0x11040, 0x20008}
+	if got := slices.Sorted(slices.Values(m.Results())); !slices.Equal(got, want) {
+		t.Fatalf("first scan: got %X, want %X", got, want)
+	}
+
+	put(heap, 0x10, 99)
+	if report = m.NextScan(scanner.NewInt32(1234)); report.Matches != 2 {
+		t.Fatalf("next scan: %+v", report)
+	}
+	m.ChangeValues(m.Results(), scanner.NewInt32(777))
+	if binary.LittleEndian.Uint32(heap[0x1040:]) != 777 || binary.LittleEndian.Uint32(exe[0x8:]) != 777 {
+		t.Fatal("change values not written")
+	}
+
+	mem.Unmap(0x20000)
+	report = m.NextScan(scanner.NewInt32(777))
+	if report.Matches != 1 || report.FailedRegions != 1 || report.Failures[0].Reason() != "EFAULT" {
+		t.Fatalf("unmapped: %+v", report)
+	}
+
+	mem.Kill()
+	if report = m.FirstScan(scanner.NewInt32(777)); !errors.Is(report.Err, ErrNoProcess) {
+		t.Fatalf("killed: got %v", report.Err)
+	}
+}
+
+func TestProcMem(t *testing.T) {
+	mem, err := NewProcMem(os.Getpid())
+	if err != nil {
+		t.Skip(err)
+	}
+	defer mem.Close()
+
+	target := make([]byte, 16, testHeapSize)
+	copy(target, "0123456789ABCDEF")
+	base := uintptr(unsafe.Pointer(&target[0]))
+
+	// 一个本地 iovec 对应两段远程地址
+	buf := make([]byte, 8)
+	local := []unix.Iovec{{Base: &buf[0], Len: uint64(len(buf))}}
+	remote := []unix.RemoteIovec{{Base: base, Len: 4}, {Base: base + 12, Len: 4}}
+	if n, err := mem.ReadV(local, remote); n != 8 || err != nil || string(buf) != "0123CDEF" {
+		t.Fatalf("read: %d %v %q", n, err, buf)
+	}
+
+	copy(buf, "wxyz")
+	remote = []unix.RemoteIovec{{Base: base + 4, Len: 4}}
+	if n, err := mem.WriteV(local[:1], remote); n != 4 || err != nil || string(target[4:8]) != "wxyz" {
+		t.Fatalf("write: %d %v %q", n, err, target)
+	}
+
+	remote = []unix.RemoteIovec{{Base: 0, Len: 4}}
+	if n, err := mem.ReadV(local, remote); n != 0 || !errors.Is(err, unix.EFAULT) {
+		t.Fatalf("bad address: %d %v", n, err)
+	}
+	runtime.KeepAlive(target)
+}
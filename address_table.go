@@ -0,0 +1,337 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+// AddressEntry 地址表中的一项, Path 可以是绝对地址, module+offset 或者多级指针
+type AddressEntry struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Group       string       `json:"group,omitempty"`
+	Path        PointerPath  `json:"path"`
+	Type        scanner.Type `json:"type"`
+	// Size Bytes, String 的字节数
+	Size     int              `json:"size,omitempty"`
+	Encoding scanner.Encoding `json:"encoding,omitempty"`
+	Frozen   bool             `json:"frozen,omitempty"`
+	// FreezeValue Frozen 时写入的值
+	FreezeValue string `json:"freeze_value,omitempty"`
+
+	// frozenAt 上次 RefreshTable 冻结的地址
+	frozenAt uint64
+}
+
+// ValueSize 读取的字节数
+func (e *AddressEntry) ValueSize() int {
+	if e.Type == scanner.Bytes || e.Type == scanner.String {
+		return e.Size
+	}
+	return e.Type.ByteSize()
+}
+
+// newValue 用于保存读取的值
+func (e *AddressEntry) newValue() *scanner.Value {
+	switch e.Type {
+	case scanner.Bytes:
+		return scanner.NewBytes(make([]byte, e.Size))
+	case scanner.String:
+		return scanner.NewText(make([]byte, e.Size), e.Encoding)
+	}
+	value := &scanner.Value{}
+	value.SetType(e.Type)
+	return value
+}
+
+// freezeValue 解析 FreezeValue
+func (e *AddressEntry) freezeValue() (*scanner.Value, error) {
+	if e.Type == scanner.String {
+		return scanner.NewString(e.FreezeValue, e.Encoding, false)
+	}
+	value := &scanner.Value{}
+	value.SetType(e.Type)
+	if err := value.FromString(e.FreezeValue); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// AddressTable 地址表 (观察列表), 以 JSON 保存, 通常每个游戏一个文件
+type AddressTable struct {
+	Game string `json:"game,omitempty"`
+	// PointerSize 解析多级指针时使用, 默认 PointerSize64
+	PointerSize int             `json:"pointer_size,omitempty"`
+	Entries     []*AddressEntry `json:"entries"`
+}
+
+func NewAddressTable(game string) *AddressTable {
+	return &AddressTable{Game: game, PointerSize: PointerSize64}
+}
+
+func (t *AddressTable) pointerSize() int {
+	if t.PointerSize == PointerSize32 {
+		return PointerSize32
+	}
+	return PointerSize64
+}
+
+func (t *AddressTable) Add(entries ...*AddressEntry) {
+	t.Entries = append(t.Entries, entries...)
+}
+
+// Remove 删除 indexes 对应的项, 无效的索引被忽略
+// 不会停止 RefreshTable 的冻结, 需要时使用 Memscan.RemoveTableEntries
+func (t *AddressTable) Remove(indexes ...int) {
+	removed := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		removed[i] = true
+	}
+	entries := t.Entries[:0]
+	for i, entry := range t.Entries {
+		if !removed[i] {
+			entries = append(entries, entry)
+		}
+	}
+	clear(t.Entries[len(entries):])
+	t.Entries = entries
+}
+
+// Groups 按出现顺序返回所有分组名称
+func (t *AddressTable) Groups() []string {
+	var groups []string
+	seen := make(map[string]bool)
+	for _, entry := range t.Entries {
+		if !seen[entry.Group] {
+			seen[entry.Group] = true
+			groups = append(groups, entry.Group)
+		}
+	}
+	return groups
+}
+
+func (t *AddressTable) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
+
+func LoadAddressTable(r io.Reader) (*AddressTable, error) {
+	t := &AddressTable{}
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, err
+	}
+	for i, entry := range t.Entries {
+		if entry == nil {
+			return nil, fmt.Errorf("address table: entry %d is empty", i)
+		}
+	}
+	return t, nil
+}
+
+// SaveFile 先写入临时文件再替换, 避免写入中断损坏原文件
+func (t *AddressTable) SaveFile(name string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err = t.Save(f); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// LoadAddressTableFile 文件不存在时返回空表
+func LoadAddressTableFile(name, game string) (*AddressTable, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewAddressTable(game), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadAddressTable(f)
+}
+
+// AddResultsToTable 将扫描结果加入地址表, retIndexes 为空时加入所有结果
+// 位于模块中的地址保存为 module+offset, 重启游戏后仍然有效
+func (m *Memscan) AddResultsToTable(t *AddressTable, retIndexes []int, valueType *scanner.Value) []*AddressEntry {
+	if m.Count() == 0 || m.snapshot != nil {
+		return nil
+	}
+	var types []scanner.Type
+	if valueType == nil || m.IsMulti() {
+		types = m.ResultTypes()
+	}
+	if types == nil && valueType == nil {
+		return nil
+	}
+
+	results := m.results.Data()
+	if len(retIndexes) == 0 {
+		retIndexes = make([]int, len(results))
+		for i := range retIndexes {
+			retIndexes[i] = i
+		}
+	}
+
+	modules := m.Modules()
+	entries := make([]*AddressEntry, 0, len(retIndexes))
+	for _, idx := range retIndexes {
+		if idx < 0 || idx >= len(results) {
+			continue
+		}
+		address := results[idx]
+		entry := &AddressEntry{
+			Name: fmt.Sprintf("%08X", address),
+			Path: PointerPath{Offset: address},
+		}
+		for _, mod := range modules {
+			if address >= mod.Base && address < mod.End {
+				entry.Path = PointerPath{Module: mod.Name, Offset: address - mod.Base}
+				break
+			}
+		}
+		if types != nil {
+			entry.Type = types[idx]
+		} else {
+			entry.Type = valueType.Type()
+			if entry.Type == scanner.Bytes || entry.Type == scanner.String {
+				entry.Size = valueType.Size()
+				entry.Encoding = valueType.Encoding()
+			}
+		}
+		entries = append(entries, entry)
+	}
+	t.Add(entries...)
+	return entries
+}
+
+// AddressValue RefreshTable 的结果, Address 为 0 表示路径无法解析, Value 为 nil 表示读取失败
+type AddressValue struct {
+	Address uint64
+	Value   *scanner.Value
+}
+
+// RefreshTable 解析所有路径并批量读取当前值
+// Frozen 的项在解析后的地址上冻结, 多级指针的地址变化时随之更新, 需要定期调用
+func (m *Memscan) RefreshTable(t *AddressTable) ([]AddressValue, error) {
+	if m.maps == nil || !m.alive() {
+		return nil, ErrNoProcess
+	}
+
+	paths := make([]PointerPath, len(t.Entries))
+	for i, entry := range t.Entries {
+		paths[i] = entry.Path
+	}
+	addresses := m.resolvePointerPaths(paths, t.pointerSize())
+
+	values := make([]AddressValue, len(t.Entries))
+	// 按大小分组, 每组批量读取
+	groups := make(map[int][]int)
+	for i, entry := range t.Entries {
+		values[i].Address = addresses[i]
+		if size := entry.ValueSize(); addresses[i] != 0 && size > 0 {
+			groups[size] = append(groups[size], i)
+		}
+	}
+
+	readAddresses := make([]uint64, 0, IOV_MAX)
+	for size, indexes := range groups {
+		buf := getReadBuffer(IOV_MAX * size)
+		for start := 0; start < len(indexes); start += IOV_MAX {
+			batch := indexes[start:min(start+IOV_MAX, len(indexes))]
+			readAddresses = readAddresses[:0]
+			for _, i := range batch {
+				readAddresses = append(readAddresses, addresses[i])
+			}
+			var failed [IOV_MAX]bool
+			m.readValuesRawFunc(readAddresses, size, buf, func(k int) {
+				failed[k] = true
+			})
+			for k, i := range batch {
+				if failed[k] {
+					continue
+				}
+				value := t.Entries[i].newValue()
+				value.SetBytes(buf[k*size : (k+1)*size])
+				values[i].Value = value
+			}
+		}
+		freeReadBuffer(buf)
+	}
+
+	for i, entry := range t.Entries {
+		address := addresses[i]
+		value, err := entry.freezeValue()
+		if !entry.Frozen || entry.FreezeValue == "" || err != nil {
+			address = 0
+		}
+		// 多级指针的地址变化或者取消冻结时, 停止冻结原来的地址
+		if entry.frozenAt != 0 && entry.frozenAt != address {
+			m.Unfreeze(entry.frozenAt)
+		}
+		entry.frozenAt = 0
+		if address != 0 && m.Freeze(address, value, FreezeAlways, 0) == nil {
+			entry.frozenAt = address
+		}
+	}
+	return values, nil
+}
+
+// RemoveTableEntries 删除 indexes 对应的项, 并停止 RefreshTable 对它们的冻结
+func (m *Memscan) RemoveTableEntries(t *AddressTable, indexes ...int) {
+	for _, i := range indexes {
+		if i >= 0 && i < len(t.Entries) {
+			m.unfreezeEntry(t.Entries[i])
+		}
+	}
+	t.Remove(indexes...)
+}
+
+// UnfreezeTable 停止 RefreshTable 对所有项的冻结, 替换地址表之前调用
+func (m *Memscan) UnfreezeTable(t *AddressTable) {
+	for _, entry := range t.Entries {
+		m.unfreezeEntry(entry)
+	}
+}
+
+func (m *Memscan) unfreezeEntry(entry *AddressEntry) {
+	if entry.frozenAt != 0 {
+		m.Unfreeze(entry.frozenAt)
+		entry.frozenAt = 0
+	}
+}
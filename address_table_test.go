@@ -0,0 +1,82 @@
+package memscan
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+func TestAddressTable_SaveLoad(t *testing.T) {
+	table := NewAddressTable("1245620")
+	table.Add(
+		&AddressEntry{Name: "HP", Group: "Player", Path: PointerPath{Module: "game.exe", Offset: 0x1A2B30, Offsets: []int64{0x18, 0x40}}, Type: scanner.Int32, Frozen: true, FreezeValue: "999"},
+		&AddressEntry{Name: "Name", Group: "Player", Path: PointerPath{Offset: 0x7FF610000}, Type: scanner.String, Size: 16, Encoding: scanner.EncodingUTF16LE},
+		&AddressEntry{Name: "Gold", Description: "inventory", Path: PointerPath{Module: "game.exe", Offset: 0x10}, Type: scanner.Uint64},
+	)
+
+	var buf bytes.Buffer
+	if err := table.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"path": "\"game.exe\"+1A2B30,18,40"`) || !strings.Contains(buf.String(), `"type": "Int32"`) {
+		t.Errorf("unexpected json:\n%s", buf.String())
+	}
+	loaded, err := LoadAddressTable(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, table) {
+		t.Errorf("got %+v, want %+v", loaded, table)
+	}
+	if got := loaded.Groups(); !reflect.DeepEqual(got, []string{"Player", ""}) {
+		t.Errorf("groups: got %q", got)
+	}
+
+	loaded.Remove(0, 2, 5)
+	if len(loaded.Entries) != 1 || loaded.Entries[0].Name != "Name" {
+		t.Errorf("remove: got %+v", loaded.Entries)
+	}
+	if value, err := table.Entries[0].freezeValue(); err != nil || value.Format() != "999" {
+		t.Errorf("freezeValue: got %v, %v", value, err)
+	}
+
+	if _, err = LoadAddressTable(strings.NewReader(`{"entries":[{"name":"x","path":"game.exe+ZZ","type":"Int32"}]}`)); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestMemscan_RemoveTableEntries(t *testing.T) {
+	const base = 0x10000
+	mem := NewFakeMemory("/fake/game")
+	if err := mem.Map(base, make([]byte, memPageSize), ParsePermissions("rw-p"), ""); err != nil {
+		t.Fatal(err)
+	}
+	m := NewMemscan()
+	if err := m.OpenAccessor(mem, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	table := NewAddressTable("")
+	for _, offset := range []uint64{0x10, 0x20, 0x30} {
+		table.Add(&AddressEntry{Path: PointerPath{Offset: base + offset}, Type: scanner.Int32, Frozen: true, FreezeValue: "1"})
+	}
+	if _, err := m.RefreshTable(table); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.FrozenEntries()) != 3 {
+		t.Fatalf("frozen: got %d", len(m.FrozenEntries()))
+	}
+
+	m.RemoveTableEntries(table, 1, 5)
+	if len(table.Entries) != 2 || m.IsFrozen(base+0x20) || !m.IsFrozen(base+0x10) || !m.IsFrozen(base+0x30) {
+		t.Fatalf("remove: %d entries, frozen %+v", len(table.Entries), m.FrozenEntries())
+	}
+	m.UnfreezeTable(table)
+	if len(m.FrozenEntries()) != 0 {
+		t.Fatalf("unfreeze table: %+v", m.FrozenEntries())
+	}
+}
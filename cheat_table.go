@@ -0,0 +1,227 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+// Cheat Engine .CT 文件 (XML)
+type ctTable struct {
+	XMLName xml.Name  `xml:"CheatTable"`
+	Entries []ctEntry `xml:"CheatEntries>CheatEntry"`
+}
+
+type ctEntry struct {
+	Description  string    `xml:"Description"`
+	VariableType string    `xml:"VariableType"`
+	Address      string    `xml:"Address"`
+	Offsets      []string  `xml:"Offsets>Offset"`
+	Length       int       `xml:"Length"`
+	Unicode      int       `xml:"Unicode"`
+	ByteLength   int       `xml:"ByteLength"`
+	ShowAsSigned int       `xml:"ShowAsSigned"`
+	GroupHeader  int       `xml:"GroupHeader"`
+	Children     []ctEntry `xml:"CheatEntries>CheatEntry"`
+}
+
+// CheatTableSkipped 无法导入的项以及原因, 例如 Auto Assembler 脚本
+type CheatTableSkipped struct {
+	Name   string
+	Group  string
+	Reason string
+}
+
+// CheatTableImport Missing 为当前进程中找不到模块的项, 它们仍然会被导入
+type CheatTableImport struct {
+	Entries []*AddressEntry
+	Skipped []CheatTableSkipped
+	Missing []*AddressEntry
+}
+
+// ParseCheatTable 解析 .CT 文件中的地址, 分组使用 "/" 连接嵌套的分组名称
+func ParseCheatTable(r io.Reader) (*CheatTableImport, error) {
+	var table ctTable
+	if err := xml.NewDecoder(r).Decode(&table); err != nil {
+		return nil, fmt.Errorf("cheat table: %w", err)
+	}
+	ret := &CheatTableImport{}
+	ret.parseEntries(table.Entries, "", nil)
+	return ret, nil
+}
+
+func (ret *CheatTableImport) parseEntries(entries []ctEntry, group string, parent *PointerPath) {
+	for _, ce := range entries {
+		name := strings.Trim(strings.TrimSpace(ce.Description), `"`)
+
+		// 分组本身也可以有地址, 子项可以使用相对于它的地址 "+10"
+		var path *PointerPath
+		if ce.GroupHeader == 0 || ce.Address != "" {
+			entry, err := ce.toEntry(name, group, parent)
+			if err != nil {
+				ret.Skipped = append(ret.Skipped, CheatTableSkipped{Name: name, Group: group, Reason: err.Error()})
+			} else {
+				ret.Entries = append(ret.Entries, entry)
+				path = &entry.Path
+			}
+		}
+
+		if len(ce.Children) > 0 {
+			childGroup := group
+			if ce.GroupHeader != 0 {
+				childGroup = strings.TrimPrefix(group+"/"+name, "/")
+			}
+			ret.parseEntries(ce.Children, childGroup, path)
+		}
+	}
+}
+
+func (ce *ctEntry) toEntry(name, group string, parent *PointerPath) (*AddressEntry, error) {
+	entry := &AddressEntry{Name: name, Group: group}
+	if err := ce.parseType(entry); err != nil {
+		return nil, err
+	}
+
+	address := strings.TrimSpace(ce.Address)
+	if address == "" {
+		return nil, fmt.Errorf("no address")
+	}
+	if address[0] == '+' || address[0] == '-' {
+		// 相对于父项的地址
+		if parent == nil {
+			return nil, fmt.Errorf("relative address %q without parent address", address)
+		}
+		off, err := parseCTOffset(address)
+		if err != nil {
+			return nil, err
+		}
+		entry.Path = *parent
+		entry.Path.Offsets = slices.Clone(parent.Offsets)
+		if n := len(entry.Path.Offsets); n > 0 {
+			entry.Path.Offsets[n-1] += off
+		} else {
+			entry.Path.Offset += uint64(off)
+		}
+	} else {
+		// 不支持符号和表达式, 例如 playerBase, [game.exe+10]+20
+		if strings.ContainsAny(address, "[]*()") {
+			return nil, fmt.Errorf("unsupported address expression %q", address)
+		}
+		path, err := ParsePointerPath(address)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported address %q", address)
+		}
+		if path.Module != "" && !strings.Contains(path.Module, ".") {
+			return nil, fmt.Errorf("unsupported symbol %q", path.Module)
+		}
+		entry.Path = path
+	}
+
+	// CT 中的偏移从最后一级开始排列
+	for i := len(ce.Offsets) - 1; i >= 0; i-- {
+		off, err := parseCTOffset(ce.Offsets[i])
+		if err != nil {
+			return nil, err
+		}
+		entry.Path.Offsets = append(entry.Path.Offsets, off)
+	}
+	return entry, nil
+}
+
+func parseCTOffset(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	v, err := parseHex(strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+"))
+	if err != nil || v > 1<<63-1 {
+		return 0, fmt.Errorf("unsupported offset %q", s)
+	}
+	if negative {
+		return -int64(v), nil
+	}
+	return int64(v), nil
+}
+
+func (ce *ctEntry) parseType(entry *AddressEntry) error {
+	signed := ce.ShowAsSigned != 0
+	pick := func(s, u scanner.Type) scanner.Type {
+		if signed {
+			return s
+		}
+		return u
+	}
+
+	switch strings.TrimSpace(ce.VariableType) {
+	case "Byte":
+		entry.Type = pick(scanner.Int8, scanner.Uint8)
+	case "2 Bytes":
+		entry.Type = pick(scanner.Int16, scanner.Uint16)
+	case "4 Bytes", "":
+		// 未指定类型时 Cheat Engine 默认使用 4 Bytes
+		entry.Type = pick(scanner.Int32, scanner.Uint32)
+	case "8 Bytes":
+		entry.Type = pick(scanner.Int64, scanner.Uint64)
+	case "Float":
+		entry.Type = scanner.Float32
+	case "Double":
+		entry.Type = scanner.Float64
+	case "String":
+		if ce.Length <= 0 {
+			return fmt.Errorf("string without length")
+		}
+		entry.Type = scanner.String
+		entry.Size = ce.Length
+		entry.Encoding = scanner.EncodingUTF8
+		if ce.Unicode != 0 {
+			entry.Size = ce.Length * 2
+			entry.Encoding = scanner.EncodingUTF16LE
+		}
+	case "Array of byte":
+		if ce.ByteLength <= 0 {
+			return fmt.Errorf("array of byte without length")
+		}
+		entry.Type = scanner.Bytes
+		entry.Size = ce.ByteLength
+	default:
+		// Auto Assembler Script, Binary, Custom 等
+		return fmt.Errorf("unsupported variable type %q", ce.VariableType)
+	}
+	return nil
+}
+
+// ImportCheatTable 解析 .CT 文件并加入地址表
+// 模块名按当前进程的 Maps 检查, 找不到的项记录在 Missing 中
+func (m *Memscan) ImportCheatTable(r io.Reader, t *AddressTable) (*CheatTableImport, error) {
+	ret, err := ParseCheatTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if m.maps != nil {
+		table := newModuleTable(m.maps.Parse(REGION_ALL))
+		for _, entry := range ret.Entries {
+			if _, ok := table.base(entry.Path.Module); !ok {
+				ret.Missing = append(ret.Missing, entry)
+			}
+		}
+	}
+	t.Add(ret.Entries...)
+	return ret, nil
+}
@@ -0,0 +1,100 @@
+package memscan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+const testCheatTable = `<?xml version="1.0" encoding="utf-8"?>
+<CheatTable CheatEngineTableVersion="45">
+  <CheatEntries>
+    <CheatEntry>
+      <ID>0</ID>
+      <Description>"Player"</Description>
+      <GroupHeader>1</GroupHeader>
+      <CheatEntries>
+        <CheatEntry>
+          <ID>1</ID>
+          <Description>"HP"</Description>
+          <ShowAsSigned>1</ShowAsSigned>
+          <VariableType>4 Bytes</VariableType>
+          <Address>"game.exe"+1A2B30</Address>
+          <Offsets>
+            <Offset>40</Offset>
+            <Offset>18</Offset>
+          </Offsets>
+          <CheatEntries>
+            <CheatEntry>
+              <ID>2</ID>
+              <Description>"MP"</Description>
+              <VariableType>Float</VariableType>
+              <Address>+4</Address>
+            </CheatEntry>
+          </CheatEntries>
+        </CheatEntry>
+        <CheatEntry>
+          <ID>3</ID>
+          <Description>"Name"</Description>
+          <VariableType>String</VariableType>
+          <Length>8</Length>
+          <Unicode>1</Unicode>
+          <Address>7FF600001000</Address>
+        </CheatEntry>
+      </CheatEntries>
+    </CheatEntry>
+    <CheatEntry>
+      <ID>4</ID>
+      <Description>"Infinite Ammo"</Description>
+      <VariableType>Auto Assembler Script</VariableType>
+      <AssemblerScript>[ENABLE]</AssemblerScript>
+    </CheatEntry>
+    <CheatEntry>
+      <ID>5</ID>
+      <Description>"Gold"</Description>
+      <VariableType>8 Bytes</VariableType>
+      <Address>[game.exe+10]+20</Address>
+    </CheatEntry>
+    <CheatEntry>
+      <ID>6</ID>
+      <Description>"Signature"</Description>
+      <VariableType>Array of byte</VariableType>
+      <ByteLength>4</ByteLength>
+      <Address>engine.dll+200</Address>
+    </CheatEntry>
+  </CheatEntries>
+</CheatTable>`
+
+func TestParseCheatTable(t *testing.T) {
+	ret, err := ParseCheatTable(strings.NewReader(testCheatTable))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*AddressEntry{
+		{Name: "HP", Group: "Player", Path: PointerPath{Module: "game.exe", Offset: 0x1A2B30, Offsets: []int64{0x18, 0x40}}, Type: scanner.Int32},
+		{Name: "MP", Group: "Player", Path: PointerPath{Module: "game.exe", Offset: 0x1A2B30, Offsets: []int64{0x18, 0x44}}, Type: scanner.Float32},
+		{Name: "Name", Group: "Player", Path: PointerPath{Offset: 0x7FF600001000}, Type: scanner.String, Size: 16, Encoding: scanner.EncodingUTF16LE},
+		{Name: "Signature", Path: PointerPath{Module: "engine.dll", Offset: 0x200}, Type: scanner.Bytes, Size: 4},
+	}
+	if !reflect.DeepEqual(ret.Entries, want) {
+		for _, e := range ret.Entries {
+			t.Logf("%+v", *e)
+		}
+		t.Error("unexpected entries")
+	}
+
+	var skipped []string
+	for _, s := range ret.Skipped {
+		skipped = append(skipped, s.Name)
+	}
+	if !reflect.DeepEqual(skipped, []string{"Infinite Ammo", "Gold"}) {
+		t.Errorf("skipped: got %v", ret.Skipped)
+	}
+
+	if _, err = ParseCheatTable(strings.NewReader("<CheatTable>")); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/kayon/memscan"
@@ -11,6 +14,8 @@ import (
 const (
 	MinResultsThreshold = 10
 	MaxResultsThreshold = 100
+
+	maxDiffRows = 1000
 )
 
 type App struct {
@@ -18,6 +23,11 @@ type App struct {
 	game                   *deck.Process
 	value                  *scanner.Value
 	renderResultsThreshold int
+	// 地址表, 不随 ResetScan 清空
+	table     *memscan.AddressTable
+	tableFile string
+	// diffBase DiffSnapshot 的基准快照
+	diffBase *memscan.Snapshot
 }
 
 func (app *App) SetRenderResultsThreshold(value int) {
@@ -58,57 +68,179 @@ func (app *App) AutoSelectGameProcess(appID int64) *deck.Process {
 // FirstScan 在此之前调用 GameProcess
 // 在UI中保存进程信息用于调试, 其它任何时候不再返回 Process
 func (app *App) FirstScan(appID int64, value string, valueType scanner.Type, option scanner.Option) *Results {
+	if err := app.openGame(appID); err != nil {
+		return scanError(err)
+	}
+
+	app.value = parseValue(value, valueType)
+
+	if app.value == nil {
+		return scanError(memscan.ErrScanValue)
+	}
+
+	app.value.WithOption(option)
+	return app.renderReport(app.scan.FirstScan(app.value))
+}
+
+// FirstScanUnknown 未知初始值扫描, 之后使用 NextScanCompare 缩小范围
+func (app *App) FirstScanUnknown(appID int64, valueType scanner.Type) *Results {
+	if !valueType.Numeric() {
+		return scanError(memscan.ErrScanValue)
+	}
+	if err := app.openGame(appID); err != nil {
+		return scanError(err)
+	}
+
+	app.value = &scanner.Value{}
+	app.value.SetType(valueType)
+
+	return app.renderReport(app.scan.FirstScanUnknown(valueType))
+}
+
+// FirstScanCompare 首次扫描 NotEqual, Greater, Less, Between
+// upper 仅用于 Between
+func (app *App) FirstScanCompare(appID int64, value, upper string, valueType scanner.Type, compare scanner.Compare) *Results {
+	if !compare.Absolute() {
+		return scanError(memscan.ErrScanValue)
+	}
+	if err := app.openGame(appID); err != nil {
+		return scanError(err)
+	}
+
+	app.value = parseCompareValue(value, upper, valueType, compare)
+	if app.value == nil {
+		return scanError(memscan.ErrScanValue)
+	}
+
+	return app.renderReport(app.scan.FirstScan(app.value))
+}
+
+// openGame 首次扫描之前重新打开进程
+func (app *App) openGame(appID int64) error {
 	app.AutoSelectGameProcess(appID)
 	if app.game == nil {
-		return nil
+		return memscan.ErrNoProcess
 	}
 	app.scan.Reset()
-	err := app.scan.Open(app.game)
-	if err != nil {
-		return nil
+	return app.scan.Open(app.game)
+}
+
+// NextScanCompare 与上一轮的值比较, 仅 IncreasedBy, DecreasedBy 需要 value
+// 或者 NotEqual, Greater, Less, Between 比较, upper 仅用于 Between
+func (app *App) NextScanCompare(compare scanner.Compare, value, upper string) *Results {
+	if app.game == nil || app.value == nil {
+		return scanError(memscan.ErrScanRound)
+	}
+	if app.scan.Rounds() < 1 {
+		return scanError(memscan.ErrScanRound)
+	}
+	if app.scan.Count() == 0 {
+		return app.render(0)
 	}
 
-	app.value = parseValue(value, valueType)
+	if compare.Absolute() {
+		scanValue := parseCompareValue(value, upper, app.value.Type(), compare)
+		if scanValue == nil {
+			return app.renderError(memscan.ErrScanValue)
+		}
+		app.value = scanValue
+		return app.renderReport(app.scan.NextScan(app.value))
+	}
 
-	if app.value == nil {
-		return nil
+	var operand *scanner.Value
+	if compare.NeedsOperand() {
+		operand = parseValue(value, app.value.Type(), true)
+		if operand == nil {
+			return app.renderError(memscan.ErrScanValue)
+		}
+	}
+	cmp, err := scanner.NewComparison(app.value.Type(), compare, operand)
+	if err != nil {
+		return app.renderError(err)
 	}
 
-	app.value.WithOption(option)
-	dur := app.scan.FirstScan(app.value)
-	return app.render(dur)
+	return app.renderReport(app.scan.NextScanCompare(cmp))
 }
 
 func (app *App) NextScan(value string) *Results {
 	if app.game == nil || app.value == nil {
-		return nil
+		return scanError(memscan.ErrScanRound)
 	}
 	if app.scan.Rounds() < 1 {
-		return nil
+		return scanError(memscan.ErrScanRound)
 	}
 	if app.scan.Count() == 0 {
 		return app.render(0)
 	}
 
-	option := app.value.Option()
-	app.value = parseValue(value, app.value.Type(), true)
-	if app.value == nil {
-		return app.render(0)
+	scanValue := parseValue(value, app.value.Type(), true)
+	if scanValue == nil {
+		return app.renderError(memscan.ErrScanValue)
 	}
-	app.value.WithOption(option)
+	scanValue.WithOption(app.value.Option())
+	app.value = scanValue
 
-	dur := app.scan.NextScan(app.value)
-	return app.render(dur)
+	return app.renderReport(app.scan.NextScan(app.value))
 }
 
 func (app *App) UndoScan() *Results {
-	if app.game == nil || app.value == nil {
+	return app.undo(func() bool {
+		return app.scan.UndoScan()
+	})
+}
+
+// UndoTo 回到历史中的 round
+func (app *App) UndoTo(round uint) *Results {
+	return app.undo(func() bool {
+		return app.scan.UndoTo(round)
+	})
+}
+
+func (app *App) undo(fn func() bool) *Results {
+	if app.game == nil || app.value == nil || !fn() {
 		return nil
 	}
-	if app.scan.UndoScan() {
-		return app.render(0)
+	// Bytes, String 每一轮的长度可能不同
+	if history := app.scan.History(); len(history) > 0 && history[len(history)-1].Value != nil {
+		app.value = history[len(history)-1].Value
+	}
+	return app.render(0)
+}
+
+// SetResultsStorage 结果保存在缓存目录的临时文件中, 减少内存占用, 会清空当前结果
+func (app *App) SetResultsStorage(enabled bool) bool {
+	var dir string
+	if enabled {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return false
+		}
+		dir = filepath.Join(cache, "memscan", "results")
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return false
+		}
+	}
+	app.value = nil
+	return app.scan.SetResultsStorage(dir) == nil
+}
+
+// GetScanProgress 在扫描期间由其它线程调用
+func (app *App) GetScanProgress() ScanProgress {
+	p := app.scan.Progress()
+	ret := ScanProgress{
+		Regions:      p.Regions,
+		TotalRegions: p.TotalRegions,
+		Bytes:        p.Bytes,
+		TotalBytes:   p.TotalBytes,
+		Matches:      p.Matches,
+		Elapsed:      p.Elapsed.Milliseconds(),
+		ETA:          p.ETA.Milliseconds(),
+		Done:         p.Done,
+	}
+	if p.TotalBytes > 0 {
+		ret.Percent = min(float64(p.Bytes)*100/float64(p.TotalBytes), 100)
 	}
-	return nil
+	return ret
 }
 
 func (app *App) ChangeValues(value string, indexes []int) *Results {
@@ -128,6 +260,151 @@ func (app *App) ChangeValues(value string, indexes []int) *Results {
 	return app.render(0)
 }
 
+// FreezeValues 在后台持续写入 value, indexes 为空时冻结所有结果
+// interval 为 0 时使用默认间隔, 冻结不受 NextScan/ResetScan 影响
+func (app *App) FreezeValues(value string, indexes []int, mode memscan.FreezeMode, interval time.Duration) *Results {
+	if app.game == nil || app.value == nil {
+		return nil
+	}
+	if app.scan.Count() == 0 || app.scan.Count() > app.renderResultsThreshold {
+		return nil
+	}
+
+	frozen := parseValue(value, app.value.Type(), true)
+	if frozen == nil {
+		return app.renderError(memscan.ErrScanValue)
+	}
+
+	if err := app.scan.FreezeResults(indexes, frozen, mode, interval); err != nil {
+		return app.renderError(err)
+	}
+	return app.render(0)
+}
+
+func (app *App) UnfreezeValues(indexes []int) *Results {
+	if app.game == nil || app.value == nil {
+		return nil
+	}
+	results := app.scan.Results()
+	for _, idx := range indexes {
+		if idx > -1 && idx < len(results) {
+			app.scan.Unfreeze(results[idx])
+		}
+	}
+	return app.render(0)
+}
+
+func (app *App) UnfreezeAll() {
+	app.scan.UnfreezeAll()
+}
+
+func (app *App) GetFrozenValues() []FrozenValue {
+	entries := app.scan.FrozenEntries()
+	values := make([]FrozenValue, len(entries))
+	for i, entry := range entries {
+		values[i] = FrozenValue{
+			Address:  fmt.Sprintf("%08X", entry.Address),
+			Value:    entry.Value.Format(),
+			Type:     entry.Value.Type(),
+			Mode:     entry.Mode,
+			Interval: entry.Interval.Milliseconds(),
+		}
+	}
+	return values
+}
+
+func (app *App) GetPatches() []PatchedValue {
+	patches := app.scan.Patches()
+	values := make([]PatchedValue, len(patches))
+	for i, patch := range patches {
+		values[i] = PatchedValue{
+			ID:       patch.ID,
+			Address:  fmt.Sprintf("%08X", patch.Address),
+			Original: fmt.Sprintf("% X", patch.Original),
+			Data:     fmt.Sprintf("% X", patch.Data),
+			Forced:   patch.Forced,
+		}
+	}
+	return values
+}
+
+// RestorePatch id 为 0 时恢复所有 Patch
+func (app *App) RestorePatch(id int) *Results {
+	var err error
+	if id == 0 {
+		err = app.scan.RestorePatches()
+	} else {
+		err = app.scan.RestorePatch(id)
+	}
+	if err != nil {
+		return app.renderError(err)
+	}
+	if app.game == nil || app.value == nil {
+		return nil
+	}
+	return app.render(0)
+}
+
+// CaptureDiffBase 保存基准快照, 替换之前的基准, 不影响扫描结果
+func (app *App) CaptureDiffBase(appID int64) *DiffResults {
+	app.AutoSelectGameProcess(appID)
+	if app.game == nil {
+		return &DiffResults{Error: memscan.ErrNoProcess.Error()}
+	}
+	if app.scan.Process() != app.game {
+		if err := app.scan.Open(app.game); err != nil {
+			return &DiffResults{Error: err.Error()}
+		}
+	}
+	snap, report := app.scan.CaptureSnapshot(memscan.REGION_ALL_RW)
+	if report.Err != nil {
+		return &DiffResults{Error: report.Err.Error()}
+	}
+	app.ReleaseDiffBase()
+	app.diffBase = snap
+	return &DiffResults{Report: convertReport(report)}
+}
+
+// DiffSnapshot 当前内存与基准比较, valueSize 为 0 时逐字节比较, 否则按 valueSize 对齐的块
+func (app *App) DiffSnapshot(valueSize int) *DiffResults {
+	if app.diffBase == nil {
+		return &DiffResults{Error: "no base snapshot"}
+	}
+	snap, report := app.scan.CaptureSnapshot(memscan.REGION_ALL_RW)
+	if report.Err != nil {
+		return &DiffResults{Error: report.Err.Error()}
+	}
+	defer snap.Destroy()
+
+	diffs := memscan.DiffSnapshots(app.diffBase, snap, memscan.DiffOptions{ValueSize: valueSize})
+	results := &DiffResults{Regions: len(diffs), Report: convertReport(report)}
+	for _, d := range diffs {
+		results.Changed += d.Changed
+		for _, r := range d.Ranges {
+			if len(results.Rows) == maxDiffRows {
+				results.Truncated = true
+				break
+			}
+			results.Rows = append(results.Rows, DiffRow{
+				Start:  fmt.Sprintf("%08X", r.Start),
+				End:    fmt.Sprintf("%08X", r.End),
+				Size:   r.Size(),
+				Type:   d.Type.String(),
+				Module: filepath.Base(d.Filename),
+				Offset: fmt.Sprintf("+%X", d.Offset(r.Start)),
+			})
+		}
+	}
+	return results
+}
+
+func (app *App) ReleaseDiffBase() {
+	if app.diffBase != nil {
+		app.diffBase.Destroy()
+		app.diffBase = nil
+	}
+}
+
 func (app *App) RefreshValues() *Results {
 	if app.game == nil || app.value == nil {
 		return nil
@@ -138,6 +415,48 @@ func (app *App) RefreshValues() *Results {
 	return app.render(0)
 }
 
+// scanError 扫描没有执行, 也没有可显示的结果
+func scanError(err error) *Results {
+	return &Results{Error: err.Error()}
+}
+
+// renderError 扫描没有执行, 显示当前结果
+func (app *App) renderError(err error) *Results {
+	results := app.render(0)
+	results.Error = err.Error()
+	return results
+}
+
+func (app *App) renderReport(report *memscan.ScanReport) *Results {
+	if report.Err != nil {
+		return app.renderError(report.Err)
+	}
+	results := app.render(report.Duration)
+	results.Report = convertReport(report)
+	return results
+}
+
+func convertReport(report *memscan.ScanReport) *ScanReport {
+	r := &ScanReport{
+		Matches:       report.Matches,
+		Regions:       report.Regions,
+		FailedRegions: report.FailedRegions,
+		Bytes:         report.Bytes,
+		Truncated:     report.Truncated,
+		Warnings:      report.Warnings,
+		Canceled:      report.Canceled,
+	}
+	for _, f := range report.Failures {
+		r.Failures = append(r.Failures, RegionFailure{
+			Start:  fmt.Sprintf("%08X", f.Start),
+			End:    fmt.Sprintf("%08X", f.End),
+			Read:   f.Read,
+			Reason: f.Reason(),
+		})
+	}
+	return r
+}
+
 func (app *App) render(dur time.Duration) *Results {
 	results := &Results{
 		Count:   app.scan.Count(),
@@ -147,8 +466,22 @@ func (app *App) render(dur time.Duration) *Results {
 	if dur > 0 {
 		results.Time = dur.String()
 	}
+	for _, entry := range app.scan.History() {
+		results.History = append(results.History, HistoryRow{
+			Round:    entry.Round,
+			Count:    entry.Count,
+			Scan:     entry.Scan,
+			Canceled: entry.Canceled,
+		})
+	}
 	if results.Count <= app.renderResultsThreshold {
 		results.List = app.scan.RenderResults(app.value)
+		results.Frozen = make([]bool, len(results.List))
+		for i, address := range app.scan.Results() {
+			if i < len(results.Frozen) {
+				results.Frozen[i] = app.scan.IsFrozen(address)
+			}
+		}
 	}
 	return results
 }
@@ -2,12 +2,15 @@ package main
 
 /*
 #include <stdlib.h>
+#include <stdbool.h>
 */
 import "C"
 import (
 	"encoding/json"
+	"time"
 	"unsafe"
 
+	"github.com/kayon/memscan"
 	"github.com/kayon/memscan/scanner"
 )
 
@@ -26,6 +29,34 @@ func SetRenderResultsThreshold(value C.int) {
 	app.SetRenderResultsThreshold(int(value))
 }
 
+// SetResultsLimit 每一轮最多保存的结果数量, 0 表示不限制
+//
+//export SetResultsLimit
+func SetResultsLimit(value C.int) {
+	app.scan.SetResultsLimit(int(value))
+}
+
+// SetResultsStorage 结果保存在磁盘上的临时文件中, 适合结果数量巨大的扫描
+//
+//export SetResultsStorage
+func SetResultsStorage(enabled C.bool) C.bool {
+	return C.bool(app.SetResultsStorage(bool(enabled)))
+}
+
+// SetForceWrites 修改数值时通过 /proc/pid/mem 写入只读页面 (例如代码段), 默认关闭
+//
+//export SetForceWrites
+func SetForceWrites(enabled C.bool) {
+	app.scan.SetForceWrites(bool(enabled))
+}
+
+// SetDirtyTracking 再次扫描时只读取上一轮之后写入过的页面, 内核不支持时返回 false
+//
+//export SetDirtyTracking
+func SetDirtyTracking(enabled C.bool) C.bool {
+	return C.bool(app.scan.SetDirtyTracking(bool(enabled)) == nil)
+}
+
 //export Version
 func Version() *C.char {
 	return returnJSON(version)
@@ -71,25 +102,167 @@ func NextScan(value *C.char) *C.char {
 	return returnJSON(results)
 }
 
+//export FirstScanUnknown
+func FirstScanUnknown(appID C.int64_t, valueType C.int) *C.char {
+	results := app.FirstScanUnknown(int64(appID), scanner.Type(valueType))
+	return returnJSON(results)
+}
+
+//export FirstScanCompare
+func FirstScanCompare(appID C.int64_t, value *C.char, upper *C.char, valueType C.int, compare C.int) *C.char {
+	results := app.FirstScanCompare(int64(appID), C.GoString(value), C.GoString(upper), scanner.Type(valueType), scanner.Compare(compare))
+	return returnJSON(results)
+}
+
+//export NextScanCompare
+func NextScanCompare(compare C.int, value *C.char, upper *C.char) *C.char {
+	results := app.NextScanCompare(scanner.Compare(compare), C.GoString(value), C.GoString(upper))
+	return returnJSON(results)
+}
+
 //export UndoScan
 func UndoScan() *C.char {
 	results := app.UndoScan()
 	return returnJSON(results)
 }
 
+// UndoTo 回到历史中的 round, 见 Results.History
+//
+//export UndoTo
+func UndoTo(round C.uint) *C.char {
+	results := app.UndoTo(uint(round))
+	return returnJSON(results)
+}
+
+// GetScanProgress 扫描会阻塞调用线程, 需要在其它线程轮询
+//
+//export GetScanProgress
+func GetScanProgress() *C.char {
+	return returnJSON(app.GetScanProgress())
+}
+
 //export ChangeValues
 func ChangeValues(value *C.char, cIndexes *C.int32_t, length C.int) *C.char {
+	results := app.ChangeValues(C.GoString(value), convertIndexes(cIndexes, length))
+	return returnJSON(results)
+}
+
+func convertIndexes(cIndexes *C.int32_t, length C.int) []int {
+	if length <= 0 {
+		return nil
+	}
 	idxSlice := unsafe.Slice((*int32)(cIndexes), int(length))
 	converted := make([]int, len(idxSlice))
 	for i, v := range idxSlice {
 		converted[i] = int(v)
 	}
-	results := app.ChangeValues(C.GoString(value), converted)
+	return converted
+}
+
+// FreezeValues interval 单位为毫秒, length 为 0 时冻结所有结果
+//
+//export FreezeValues
+func FreezeValues(value *C.char, cIndexes *C.int32_t, length C.int, mode C.int, interval C.int) *C.char {
+	results := app.FreezeValues(C.GoString(value), convertIndexes(cIndexes, length), memscan.FreezeMode(mode), time.Duration(interval)*time.Millisecond)
+	return returnJSON(results)
+}
+
+//export UnfreezeValues
+func UnfreezeValues(cIndexes *C.int32_t, length C.int) *C.char {
+	results := app.UnfreezeValues(convertIndexes(cIndexes, length))
+	return returnJSON(results)
+}
+
+//export UnfreezeAll
+func UnfreezeAll() {
+	app.UnfreezeAll()
+}
+
+//export GetFrozenValues
+func GetFrozenValues() *C.char {
+	return returnJSON(app.GetFrozenValues())
+}
+
+//export GetPatches
+func GetPatches() *C.char {
+	return returnJSON(app.GetPatches())
+}
+
+// RestorePatch id 为 0 时恢复所有 Patch
+//
+//export RestorePatch
+func RestorePatch(id C.int) *C.char {
+	results := app.RestorePatch(int(id))
 	return returnJSON(results)
 }
 
+//export CaptureDiffBase
+func CaptureDiffBase(appID C.int64_t) *C.char {
+	return returnJSON(app.CaptureDiffBase(int64(appID)))
+}
+
+// DiffSnapshot valueSize 为 0 时逐字节比较
+//
+//export DiffSnapshot
+func DiffSnapshot(valueSize C.int) *C.char {
+	return returnJSON(app.DiffSnapshot(int(valueSize)))
+}
+
+//export ReleaseDiffBase
+func ReleaseDiffBase() {
+	app.ReleaseDiffBase()
+}
+
 //export RefreshValues
 func RefreshValues() *C.char {
 	results := app.RefreshValues()
 	return returnJSON(results)
 }
+
+//export LoadAddressTable
+func LoadAddressTable(appID C.int64_t) *C.char {
+	return returnJSON(app.LoadAddressTable(int64(appID)))
+}
+
+// AddResultsToTable length 为 0 时加入所有结果
+//
+//export AddResultsToTable
+func AddResultsToTable(cIndexes *C.int32_t, length C.int) *C.char {
+	return returnJSON(app.AddResultsToTable(convertIndexes(cIndexes, length)))
+}
+
+//export RemoveTableEntries
+func RemoveTableEntries(cIndexes *C.int32_t, length C.int) *C.char {
+	return returnJSON(app.RemoveTableEntries(convertIndexes(cIndexes, length)))
+}
+
+//export EditTableEntry
+func EditTableEntry(index C.int, name, description, group, path *C.char) *C.char {
+	rows := app.EditTableEntry(int(index), C.GoString(name), C.GoString(description), C.GoString(group), C.GoString(path))
+	return returnJSON(rows)
+}
+
+//export FreezeTableEntry
+func FreezeTableEntry(index C.int, value *C.char, frozen C.bool) *C.char {
+	return returnJSON(app.FreezeTableEntry(int(index), C.GoString(value), bool(frozen)))
+}
+
+//export RefreshTable
+func RefreshTable() *C.char {
+	return returnJSON(app.RefreshTable())
+}
+
+//export ImportCheatTable
+func ImportCheatTable(path *C.char) *C.char {
+	return returnJSON(app.ImportCheatTable(C.GoString(path)))
+}
+
+//export SaveSession
+func SaveSession() C.bool {
+	return C.bool(app.SaveSession())
+}
+
+//export LoadSession
+func LoadSession(appID C.int64_t) *C.char {
+	return returnJSON(app.LoadSession(int64(appID)))
+}
@@ -34,9 +34,91 @@ var app *App
 type Results struct {
 	Count   int
 	List    [][2]string
+	Frozen  []bool
 	Round   uint
 	Time    string
 	CanUndo bool
+	// History 按轮次排列, 最后一项为当前结果
+	History []HistoryRow
+	// Report 本次扫描的报告, 非扫描操作为 nil
+	Report *ScanReport
+	// Error 扫描没有执行的原因, 此时其它字段为当前结果
+	Error string
+}
+
+type ScanReport struct {
+	Matches       int
+	Regions       int
+	FailedRegions int
+	Failures      []RegionFailure
+	Bytes         uint64
+	Truncated     bool
+	Warnings      []string
+	Canceled      bool
+}
+
+type RegionFailure struct {
+	Start  string
+	End    string
+	Read   uint64
+	Reason string // EFAULT, EPERM, ESRCH
+}
+
+type HistoryRow struct {
+	Round    uint
+	Count    int
+	Scan     string
+	Canceled bool
+}
+
+// ScanProgress 扫描进度, 供 UI 在扫描期间轮询
+type ScanProgress struct {
+	Regions      int
+	TotalRegions int
+	Bytes        uint64
+	TotalBytes   uint64
+	Matches      uint64
+	Percent      float64
+	Elapsed      int64 // 毫秒
+	ETA          int64 // 毫秒
+	Done         bool
+}
+
+type FrozenValue struct {
+	Address  string
+	Value    string
+	Type     scanner.Type
+	Mode     memscan.FreezeMode
+	Interval int64 // 毫秒
+}
+
+// DiffResults 当前内存与基准快照比较的结果, Rows 最多 maxDiffRows 行
+type DiffResults struct {
+	Regions   int
+	Changed   uint64
+	Rows      []DiffRow
+	Truncated bool
+	Report    *ScanReport
+	Error     string
+}
+
+// DiffRow 改变的一段字节, Offset 相对 Module 的基址
+type DiffRow struct {
+	Start  string
+	End    string
+	Size   uint64
+	Type   string
+	Module string
+	Offset string
+}
+
+// PatchedValue SetForceWrites 写入只读页面或 WritePatch 时备份的原始数据
+type PatchedValue struct {
+	ID       int
+	Address  string
+	Original string
+	Data     string
+	Forced   bool
 }
 
 func init() {
@@ -55,6 +137,15 @@ func parseValue(rawValue string, valueType scanner.Type, args ...bool) *scanner.
 		allowZeroValue = args[0]
 	}
 
+	// Bytes 支持特征码, 例如 "48 8B ?? ?? 05 ?? 00"
+	if valueType == scanner.Bytes {
+		value, err := scanner.NewPattern(rawValue)
+		if err != nil {
+			return nil
+		}
+		return value
+	}
+
 	rawValue = strings.TrimLeft(rawValue, "0")
 	if rawValue == "" {
 		rawValue = "0"
@@ -67,20 +158,36 @@ func parseValue(rawValue string, valueType scanner.Type, args ...bool) *scanner.
 	var value *scanner.Value
 	switch valueType {
 	case scanner.Int8:
-		if i, err := strconv.ParseUint(rawValue, 10, 8); err == nil {
+		if i, err := parseInt(rawValue, 8); err == nil {
 			value = scanner.NewInt8(int8(i))
 		}
 	case scanner.Int16:
-		if i, err := strconv.ParseUint(rawValue, 10, 16); err == nil {
+		if i, err := parseInt(rawValue, 16); err == nil {
 			value = scanner.NewInt16(int16(i))
 		}
 	case scanner.Int32:
-		if i, err := strconv.ParseUint(rawValue, 10, 32); err == nil {
+		if i, err := parseInt(rawValue, 32); err == nil {
 			value = scanner.NewInt32(int32(i))
 		}
 	case scanner.Int64:
+		if i, err := parseInt(rawValue, 64); err == nil {
+			value = scanner.NewInt64(i)
+		}
+	case scanner.Uint8:
+		if i, err := strconv.ParseUint(rawValue, 10, 8); err == nil {
+			value = scanner.NewUint8(uint8(i))
+		}
+	case scanner.Uint16:
+		if i, err := strconv.ParseUint(rawValue, 10, 16); err == nil {
+			value = scanner.NewUint16(uint16(i))
+		}
+	case scanner.Uint32:
+		if i, err := strconv.ParseUint(rawValue, 10, 32); err == nil {
+			value = scanner.NewUint32(uint32(i))
+		}
+	case scanner.Uint64:
 		if i, err := strconv.ParseUint(rawValue, 10, 64); err == nil {
-			value = scanner.NewInt64(int64(i))
+			value = scanner.NewUint64(i)
 		}
 	case scanner.Float32:
 		if i, err := strconv.ParseFloat(rawValue, 32); err == nil {
@@ -102,3 +209,36 @@ func parseValue(rawValue string, valueType scanner.Type, args ...bool) *scanner.
 
 	return value
 }
+
+// parseInt 有符号整数, 兼容以无符号形式输入的位模式 (例如 Int8 的 255 即 -1)
+func parseInt(rawValue string, bitSize int) (int64, error) {
+	i, err := strconv.ParseInt(rawValue, 10, bitSize)
+	if err == nil {
+		return i, nil
+	}
+	u, uerr := strconv.ParseUint(rawValue, 10, bitSize)
+	if uerr != nil {
+		return 0, err
+	}
+	return int64(u), nil
+}
+
+func parseCompareValue(rawValue, rawUpper string, valueType scanner.Type, compare scanner.Compare) *scanner.Value {
+	value := parseValue(rawValue, valueType, true)
+	if value == nil {
+		return nil
+	}
+	if compare == scanner.CompareBetween {
+		upper := parseValue(rawUpper, valueType, true)
+		if upper == nil {
+			return nil
+		}
+		value.WithBetween(upper)
+	} else {
+		value.WithCompare(compare)
+	}
+	if !value.HasCompare() {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/kayon/memscan"
+)
+
+// sessionFile 每个游戏一个会话文件, 插件重新加载后恢复扫描
+func sessionFile(appID int64) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "memscan", "sessions", strconv.FormatInt(appID, 10)+".session"), nil
+}
+
+// SaveSession 没有扫描结果时删除原来的会话文件
+func (app *App) SaveSession() bool {
+	if app.game == nil {
+		return false
+	}
+	name, err := sessionFile(app.game.AppID)
+	if err != nil {
+		return false
+	}
+	if app.scan.Rounds() == 0 {
+		_ = os.Remove(name)
+		return true
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return false
+	}
+
+	tmp := name + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return false
+	}
+	err = app.scan.SaveSession(f, app.value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return false
+	}
+	return os.Rename(tmp, name) == nil
+}
+
+// LoadSession 游戏已经重启时删除过期的会话文件
+func (app *App) LoadSession(appID int64) *Results {
+	name, err := sessionFile(appID)
+	if err != nil {
+		return nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	app.AutoSelectGameProcess(appID)
+	if app.game == nil {
+		return nil
+	}
+	if err = app.scan.Open(app.game); err != nil {
+		return nil
+	}
+	value, err := app.scan.LoadSession(f)
+	if errors.Is(err, memscan.ErrSessionProcess) || errors.Is(err, memscan.ErrSessionFile) {
+		_ = os.Remove(name)
+	}
+	if err != nil || value == nil {
+		app.ResetScan()
+		return nil
+	}
+	app.value = value
+	return app.render(0)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/kayon/memscan"
+)
+
+type TableRow struct {
+	Name        string
+	Description string
+	Group       string
+	Path        string
+	Type        string
+	Address     string
+	Value       string
+	Frozen      bool
+}
+
+// tableFile 每个游戏一个地址表文件
+func tableFile(appID int64) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "memscan", "tables", strconv.FormatInt(appID, 10)+".json"), nil
+}
+
+// LoadAddressTable 切换游戏时加载对应的地址表
+func (app *App) LoadAddressTable(appID int64) []TableRow {
+	name, err := tableFile(appID)
+	if err != nil {
+		return nil
+	}
+	table, err := memscan.LoadAddressTableFile(name, strconv.FormatInt(appID, 10))
+	if err != nil {
+		return nil
+	}
+	if app.table != nil {
+		app.scan.UnfreezeTable(app.table)
+	}
+	app.tableFile = name
+	app.table = table
+
+	// 没有扫描过时也需要打开进程
+	app.AutoSelectGameProcess(appID)
+	if app.game != nil {
+		if proc := app.scan.Process(); proc == nil || proc.PID != app.game.PID {
+			_ = app.scan.Open(app.game)
+		}
+	}
+	return app.RefreshTable()
+}
+
+func (app *App) saveTable() {
+	if app.table != nil && app.tableFile != "" {
+		_ = app.table.SaveFile(app.tableFile)
+	}
+}
+
+// AddResultsToTable indexes 为空时加入所有结果
+func (app *App) AddResultsToTable(indexes []int) []TableRow {
+	if app.table == nil || app.game == nil {
+		return nil
+	}
+	if app.scan.Count() == 0 || app.scan.Count() > app.renderResultsThreshold {
+		return nil
+	}
+	app.scan.AddResultsToTable(app.table, indexes, app.value)
+	app.saveTable()
+	return app.RefreshTable()
+}
+
+func (app *App) RemoveTableEntries(indexes []int) []TableRow {
+	if app.table == nil {
+		return nil
+	}
+	app.scan.RemoveTableEntries(app.table, indexes...)
+	app.saveTable()
+	return app.RefreshTable()
+}
+
+// EditTableEntry path 为空时保持不变
+func (app *App) EditTableEntry(index int, name, description, group, path string) []TableRow {
+	if app.table == nil || index < 0 || index >= len(app.table.Entries) {
+		return nil
+	}
+	entry := app.table.Entries[index]
+	if path != "" {
+		p, err := memscan.ParsePointerPath(path)
+		if err != nil {
+			return nil
+		}
+		entry.Path = p
+	}
+	entry.Name = name
+	entry.Description = description
+	entry.Group = group
+	app.saveTable()
+	return app.RefreshTable()
+}
+
+// FreezeTableEntry frozen 为 true 时在后台持续写入 value
+func (app *App) FreezeTableEntry(index int, value string, frozen bool) []TableRow {
+	if app.table == nil || index < 0 || index >= len(app.table.Entries) {
+		return nil
+	}
+	entry := app.table.Entries[index]
+	if frozen {
+		if parseValue(value, entry.Type, true) == nil {
+			return nil
+		}
+		entry.FreezeValue = value
+	}
+	entry.Frozen = frozen
+	app.saveTable()
+	return app.RefreshTable()
+}
+
+// RefreshTable 读取所有项的当前值, 无法解析或读取时显示 "??"
+func (app *App) RefreshTable() []TableRow {
+	if app.table == nil {
+		return nil
+	}
+	values, _ := app.scan.RefreshTable(app.table)
+	rows := make([]TableRow, len(app.table.Entries))
+	for i, entry := range app.table.Entries {
+		rows[i] = TableRow{
+			Name:        entry.Name,
+			Description: entry.Description,
+			Group:       entry.Group,
+			Path:        entry.Path.Format(),
+			Type:        entry.Type.String(),
+			Address:     "??",
+			Value:       "??",
+			Frozen:      entry.Frozen,
+		}
+		if i >= len(values) {
+			continue
+		}
+		if values[i].Address != 0 {
+			rows[i].Address = fmt.Sprintf("%08X", values[i].Address)
+		}
+		if values[i].Value != nil {
+			rows[i].Value = values[i].Value.Format()
+		}
+	}
+	return rows
+}
+
+type CheatTableReport struct {
+	Imported int
+	Skipped  []memscan.CheatTableSkipped
+	// Missing 当前进程中找不到模块的项名称
+	Missing []string
+	Rows    []TableRow
+}
+
+// ImportCheatTable 导入 Cheat Engine .CT 文件到当前地址表
+func (app *App) ImportCheatTable(path string) *CheatTableReport {
+	if app.table == nil {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	ret, err := app.scan.ImportCheatTable(f, app.table)
+	if err != nil {
+		return nil
+	}
+	app.saveTable()
+
+	report := &CheatTableReport{
+		Imported: len(ret.Entries),
+		Skipped:  ret.Skipped,
+	}
+	for _, entry := range ret.Missing {
+		report.Missing = append(report.Missing, entry.Name)
+	}
+	report.Rows = app.RefreshTable()
+	return report
+}
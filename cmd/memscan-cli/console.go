@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -17,8 +18,13 @@ import (
 const (
 	ConsoleStepSelectGame = iota
 	ConsoleStepSelectType
+	ConsoleStepSelectCompare
 	ConsoleStepEnterScanValue
+	ConsoleStepEnterUpperValue
 	ConsoleStepEnterChangeValue
+	ConsoleStepEnterFreezeValue
+	ConsoleStepSelectAction
+	ConsoleStepSelectHistory
 	ConsoleStepFirstScan
 	ConsoleStepNext
 	ConsoleStepNextScan
@@ -34,14 +40,26 @@ type Console struct {
 	step        uint8
 	selectIndex int
 	value       *scanner.Value
-	scanCount   int
-	mscan       *memscan.Memscan
-	lastScan    time.Duration
-	quit        chan os.Signal
+	upper       *scanner.Value
+	compare     scanner.Compare
+	unknown     bool
+	// 搜索所有数值类型
+	allTypes  bool
+	multi     *scanner.Multi
+	scanCount int
+	mscan     *memscan.Memscan
+	// image 离线扫描的快照或 core 文件
+	image    *memscan.MemoryImage
+	lastScan time.Duration
+	quit     chan os.Signal
 }
 
 func (console *Console) Close() error {
-	return console.mscan.Close()
+	err := console.mscan.Close()
+	if console.image != nil {
+		_ = console.image.Close()
+	}
+	return err
 }
 
 func (console *Console) Run() {
@@ -52,9 +70,18 @@ Loop:
 			console.selectGame()
 		case ConsoleStepSelectType:
 			console.selectValueType()
+		case ConsoleStepSelectCompare:
+			console.selectCompare()
 		case ConsoleStepEnterScanValue,
-			ConsoleStepEnterChangeValue:
+			ConsoleStepEnterChangeValue,
+			ConsoleStepEnterFreezeValue:
 			console.enterScanValue()
+		case ConsoleStepSelectAction:
+			console.selectAction()
+		case ConsoleStepSelectHistory:
+			console.selectHistory()
+		case ConsoleStepEnterUpperValue:
+			console.enterUpperValue()
 		case ConsoleStepFirstScan:
 			console.firstScan()
 		case ConsoleStepNext:
@@ -78,22 +105,38 @@ func (console *Console) label() string {
 	case ConsoleStepSelectType:
 		help = "Press [J] [K] to navigate"
 		label = colorLabel.Sprintf("<VALUE TYPE>")
+	case ConsoleStepSelectCompare:
+		help = "Press [J] [K] to navigate"
+		label = colorLabel.Sprintf("<SCAN MODE>")
 	case ConsoleStepEnterScanValue:
-		if console.mscan.Rounds() == 0 {
+		if console.compare.NeedsOperand() {
+			help = fmt.Sprintf("Enter the amount %s", console.compare)
+			label = colorLabel.Sprintf("<NEXT SCAN>")
+		} else if console.mscan.Rounds() == 0 {
 			help = "Enter first scan value"
 			label = colorLabel.Sprintf("<SCAN VALUE>")
 		} else {
 			help = "Enter next scan value"
 			label = colorLabel.Sprintf("<NEXT SCAN>")
 		}
-	case ConsoleStepEnterChangeValue:
+		if console.compare.Absolute() {
+			help = fmt.Sprintf("Enter the value to compare (%s)", console.compare)
+		}
+	case ConsoleStepEnterUpperValue:
+		help = "Enter the upper bound"
+		label = colorLabel.Sprintf("<BETWEEN>")
+	case ConsoleStepEnterChangeValue, ConsoleStepEnterFreezeValue:
 		results := console.mscan.Results()
 		if console.selectIndex > -1 {
 			help = fmt.Sprintf("%08X", results[console.selectIndex])
 		} else {
 			help = "All"
 		}
-		label = colorLabel.Sprintf("<CHANGE VALUE>")
+		if console.step == ConsoleStepEnterFreezeValue {
+			label = colorLabel.Sprintf("<FREEZE VALUE>")
+		} else {
+			label = colorLabel.Sprintf("<CHANGE VALUE>")
+		}
 	case ConsoleStepNext:
 		help = "Press [J] [K] to navigate"
 		label = colorLabel.Sprintf("%s (%s)", console.mscan, console.lastScan)
@@ -139,6 +182,15 @@ func (console *Console) selectGame() {
 	console.step = ConsoleStepSelectType
 }
 
+// openImage 代替 selectGame, 只读, 无法修改和冻结
+func (console *Console) openImage(path string) {
+	img, err := memscan.OpenImage(path)
+	console.checkError(err)
+	console.image = img
+	console.checkError(console.mscan.OpenAccessor(img, nil))
+	console.step = ConsoleStepSelectType
+}
+
 func (console *Console) selectValueType() {
 	templates := &promptui.SelectTemplates{
 		Label:    "{{ . }}",
@@ -147,29 +199,164 @@ func (console *Console) selectValueType() {
 		Selected: "Scan Type > {{ . | red }}",
 	}
 
-	items := []scanner.Type{
-		scanner.Bytes,
-		scanner.Int8,
-		scanner.Int16,
-		scanner.Int32,
-		scanner.Int64,
-		scanner.Float32,
-		scanner.Float64,
+	items := []typeItem{
+		{typ: scanner.Bytes},
+		{typ: scanner.Int8},
+		{typ: scanner.Int16},
+		{typ: scanner.Int32},
+		{typ: scanner.Int64},
+		{typ: scanner.Uint8},
+		{typ: scanner.Uint16},
+		{typ: scanner.Uint32},
+		{typ: scanner.Uint64},
+		{typ: scanner.Float32},
+		{typ: scanner.Float64},
+		{typ: scanner.String},
+		{typ: scanner.Int32, all: true},
 	}
 
 	prompt := promptui.Select{
 		Label:     console.label(),
 		Items:     items,
 		Templates: templates,
-		Size:      7,
+		Size:      13,
 	}
 	prompt.HideHelp = true
 
 	i, _, err := prompt.RunCursorAt(3, 0)
 	console.checkError(err)
 
-	console.value.SetType(items[i])
-	console.step = ConsoleStepEnterScanValue
+	console.value.SetType(items[i].typ)
+	console.allTypes = items[i].all
+	console.multi = nil
+	console.step = ConsoleStepSelectCompare
+}
+
+type typeItem struct {
+	typ scanner.Type
+	all bool
+}
+
+func (item typeItem) String() string {
+	if item.all {
+		return "All Numeric"
+	}
+	return item.typ.String()
+}
+
+type compareItem struct {
+	Label   string
+	Compare scanner.Compare
+	Unknown bool
+}
+
+func (console *Console) selectCompare() {
+	console.compare = scanner.CompareEqual
+	console.unknown = false
+
+	// Bytes, String, 所有数值类型只能搜索精确值
+	if !console.value.Type().Numeric() || console.allTypes {
+		if console.value.Type() == scanner.String && console.mscan.Rounds() == 0 {
+			console.selectEncoding()
+		}
+		console.step = ConsoleStepEnterScanValue
+		return
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "> {{ .Label | red }}",
+		Inactive: "  {{ .Label }}",
+		Selected: "Scan Mode > {{ .Label | red }}",
+	}
+
+	var items []compareItem
+	if console.mscan.Rounds() == 0 {
+		items = []compareItem{
+			{Label: "Exact Value", Compare: scanner.CompareEqual},
+			{Label: "Unknown Initial Value", Unknown: true},
+			{Label: "Not Equal", Compare: scanner.CompareNotEqual},
+			{Label: "Greater Than", Compare: scanner.CompareGreater},
+			{Label: "Less Than", Compare: scanner.CompareLess},
+			{Label: "Between", Compare: scanner.CompareBetween},
+		}
+	} else {
+		items = []compareItem{
+			{Label: "Exact Value", Compare: scanner.CompareEqual},
+			{Label: "Changed", Compare: scanner.CompareChanged},
+			{Label: "Unchanged", Compare: scanner.CompareUnchanged},
+			{Label: "Increased", Compare: scanner.CompareIncreased},
+			{Label: "Decreased", Compare: scanner.CompareDecreased},
+			{Label: "Increased By", Compare: scanner.CompareIncreasedBy},
+			{Label: "Decreased By", Compare: scanner.CompareDecreasedBy},
+			{Label: "Not Equal", Compare: scanner.CompareNotEqual},
+			{Label: "Greater Than", Compare: scanner.CompareGreater},
+			{Label: "Less Than", Compare: scanner.CompareLess},
+			{Label: "Between", Compare: scanner.CompareBetween},
+		}
+	}
+
+	prompt := promptui.Select{
+		Label:     console.label(),
+		Items:     items,
+		Templates: templates,
+		Size:      len(items),
+	}
+	prompt.HideHelp = true
+
+	i, _, err := prompt.Run()
+	console.checkError(err)
+
+	item := items[i]
+	console.compare = item.Compare
+	console.unknown = item.Unknown
+
+	switch {
+	case item.Unknown:
+		console.step = ConsoleStepFirstScan
+	case item.Compare.Relative() && !item.Compare.NeedsOperand():
+		console.step = ConsoleStepNextScan
+	default:
+		console.step = ConsoleStepEnterScanValue
+	}
+}
+
+type encodingItem struct {
+	Label      string
+	Encoding   scanner.Encoding
+	IgnoreCase bool
+}
+
+func (console *Console) selectEncoding() {
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "> {{ .Label | red }}",
+		Inactive: "  {{ .Label }}",
+		Selected: "Encoding > {{ .Label | red }}",
+	}
+
+	items := []encodingItem{
+		{Label: "UTF-8", Encoding: scanner.EncodingUTF8},
+		{Label: "UTF-8 (Ignore Case)", Encoding: scanner.EncodingUTF8, IgnoreCase: true},
+		{Label: "UTF-16LE", Encoding: scanner.EncodingUTF16LE},
+		{Label: "UTF-16LE (Ignore Case)", Encoding: scanner.EncodingUTF16LE, IgnoreCase: true},
+		{Label: "ASCII", Encoding: scanner.EncodingASCII},
+		{Label: "ASCII (Ignore Case)", Encoding: scanner.EncodingASCII, IgnoreCase: true},
+	}
+
+	prompt := promptui.Select{
+		Label:     console.label(),
+		Items:     items,
+		Templates: templates,
+		Size:      len(items),
+	}
+	prompt.HideHelp = true
+
+	i, _, err := prompt.Run()
+	console.checkError(err)
+
+	console.value.WithEncoding(items[i].Encoding)
+	console.value.WithIgnoreCase(items[i].IgnoreCase)
 }
 
 func (console *Console) enterScanValue() {
@@ -180,10 +367,32 @@ func (console *Console) enterScanValue() {
 		Success: "{{ . }} ",
 	}
 
+	isNextScan := console.mscan.Rounds() > 0
+	isFreeze := console.step == ConsoleStepEnterFreezeValue
+	// 冻结与修改值相同, 只是在后台持续写入
+	isChangeValue := console.step == ConsoleStepEnterChangeValue || isFreeze
+
+	// 所有数值类型, 修改单个结果时使用它自己的类型
+	multiInput := console.allTypes && !(isChangeValue && console.selectIndex > -1)
+	if console.allTypes && !multiInput {
+		console.value.SetType(console.mscan.ResultTypes()[console.selectIndex])
+	}
+
 	validate := func(input string) error {
+		if multiInput {
+			multi, err := scanner.NewMulti(input, scanner.OptionFloatUnrounded)
+			if err != nil {
+				return err
+			}
+			console.multi = multi
+			return nil
+		}
 		err := console.value.FromString(input)
 		if err != nil {
-			return &inputError{typ: console.value.Type()}
+			return &inputError{typ: console.value.Type(), enc: console.value.Encoding()}
+		}
+		if isChangeValue && console.value.IsPattern() {
+			return errors.New("invalid input. wildcards cannot be written")
 		}
 		return nil
 	}
@@ -197,23 +406,40 @@ func (console *Console) enterScanValue() {
 	value, err := prompt.Run()
 	console.checkError(err)
 
-	isNextScan := console.mscan.Rounds() > 0
-	isChangeValue := console.step == ConsoleStepEnterChangeValue
-
 	label := "Scan Value"
 	var retIndexes = make([]int, 0, 1)
 	if isChangeValue {
 		label = "Change All"
+		if isFreeze {
+			label = "Freeze"
+		}
 		if console.selectIndex > -1 {
 			retIndexes = append(retIndexes, console.selectIndex)
 		}
 	}
 
-	fmt.Printf("\u001B[1A\u001B[2K\r%s > %s (%s)\n", label, color.RedString(value), console.value.String())
+	if multiInput {
+		fmt.Printf("\u001B[1A\u001B[2K\r%s > %s (All Numeric)\n", label, color.RedString(value))
+	} else {
+		fmt.Printf("\u001B[1A\u001B[2K\r%s > %s (%s)\n", label, color.RedString(value), console.value.String())
+	}
 
 	if isChangeValue {
-		console.mscan.ChangeResultsValues(retIndexes, console.value)
+		values := []*scanner.Value{console.value}
+		if multiInput {
+			// ChangeResultsValues, FreezeResults 只写入类型相同的结果
+			values = console.multi.Values()
+		}
+		for _, v := range values {
+			if isFreeze {
+				_ = console.mscan.FreezeResults(retIndexes, v, memscan.FreezeAlways, 0)
+			} else {
+				console.mscan.ChangeResultsValues(retIndexes, v)
+			}
+		}
 		console.step = ConsoleStepNext
+	} else if console.compare == scanner.CompareBetween {
+		console.step = ConsoleStepEnterUpperValue
 	} else if isNextScan {
 		console.step = ConsoleStepNextScan
 	} else {
@@ -221,11 +447,84 @@ func (console *Console) enterScanValue() {
 	}
 }
 
+func (console *Console) enterUpperValue() {
+	templates := &promptui.PromptTemplates{
+		Prompt:  "{{ . }} ",
+		Valid:   "{{ . | green }} ",
+		Invalid: "{{ . | red }} ",
+		Success: "{{ . }} ",
+	}
+
+	upper := &scanner.Value{}
+	upper.SetType(console.value.Type())
+
+	validate := func(input string) error {
+		err := upper.FromString(input)
+		if err != nil {
+			return &inputError{typ: upper.Type()}
+		}
+		return nil
+	}
+
+	prompt := promptui.Prompt{
+		Label:     console.label(),
+		Templates: templates,
+		Validate:  validate,
+	}
+
+	value, err := prompt.Run()
+	console.checkError(err)
+
+	fmt.Printf("\u001B[1A\u001B[2K\r%s > %s (%s)\n", "Upper Value", color.RedString(value), upper.String())
+
+	console.upper = upper
+	if console.mscan.Rounds() > 0 {
+		console.step = ConsoleStepNextScan
+	} else {
+		console.step = ConsoleStepFirstScan
+	}
+}
+
+// scanValue 按 console.compare 生成扫描使用的值
+func (console *Console) scanValue() *scanner.Value {
+	value := *console.value
+	if console.compare == scanner.CompareBetween {
+		value.WithBetween(console.upper)
+	} else {
+		value.WithCompare(console.compare)
+	}
+	return &value
+}
+
 func (console *Console) firstScan() {
-	console.lastScan = console.mscan.FirstScan(console.value)
+	if console.unknown {
+		console.report(console.mscan.FirstScanUnknown(console.value.Type()))
+	} else if console.allTypes {
+		console.report(console.mscan.FirstScanMulti(console.multi))
+	} else {
+		console.report(console.mscan.FirstScan(console.scanValue()))
+	}
 	console.step = ConsoleStepNext
 }
 
+// report 显示扫描的错误和警告
+func (console *Console) report(report *memscan.ScanReport) {
+	console.lastScan = report.Duration
+	if report.Err != nil {
+		color.Red("ERROR: %v", report.Err)
+		return
+	}
+	if report.FailedRegions > 0 {
+		color.Yellow("WARNING: %d regions could not be fully read", report.FailedRegions)
+	}
+	for _, warning := range report.Warnings {
+		color.Yellow("WARNING: %s", warning)
+	}
+	if report.Canceled {
+		color.Yellow("WARNING: scan canceled")
+	}
+}
+
 func (console *Console) next() {
 	console.selectIndex = -1
 
@@ -241,10 +540,16 @@ func (console *Console) next() {
 
 	if console.mscan.Count() <= 10 {
 		display := console.mscan.RenderResults(console.value)
+		results := console.mscan.Results()
 		opts = len(display)
-		items = make([]string, 0, opts+2)
+		items = make([]string, 0, opts+5)
 		for i, v := range display {
-			items = append(items, fmt.Sprintf("%2d. [%s] %s", i, v[0], v[1]))
+			// 冻结的结果以 * 标记
+			mark := " "
+			if i < len(results) && console.mscan.IsFrozen(results[i]) {
+				mark = "*"
+			}
+			items = append(items, fmt.Sprintf("%2d.%s[%s] %s", i, mark, v[0], v[1]))
 		}
 	}
 	items = append(items, "Next Scan")
@@ -253,9 +558,15 @@ func (console *Console) next() {
 	} else {
 		items = append(items, "New Scan")
 		if count > 1 && opts > 0 {
-			items = append(items, "Change All")
+			items = append(items, "Change All", "Freeze All")
 		}
 	}
+	if console.mscan.CanUndo() {
+		items = append(items, "History")
+	}
+	if len(console.mscan.FrozenEntries()) > 0 {
+		items = append(items, "Unfreeze All")
+	}
 
 	prompt := promptui.Select{
 		Label:     console.label(),
@@ -265,34 +576,134 @@ func (console *Console) next() {
 	}
 	prompt.HideHelp = true
 
-	i, _, err := prompt.RunCursorAt(0, 0)
+	i, item, err := prompt.RunCursorAt(0, 0)
 	console.checkError(err)
 
 	fmt.Print("\u001B[1A\u001B[2K")
 
-	switch i {
-	// Next Scan | New Scan
-	case opts:
+	if i < opts {
+		// Change Value | Freeze | Unfreeze
+		console.selectIndex = i
+		console.step = ConsoleStepSelectAction
+		return
+	}
+
+	switch item {
+	case "Next Scan":
+		console.step = ConsoleStepSelectCompare
+	case "New Scan":
+		console.mscan.Reset()
 		if count == 0 {
-			console.mscan.Reset()
+			console.step = ConsoleStepSelectCompare
+		} else {
+			console.step = ConsoleStepSelectType
 		}
-		console.step = ConsoleStepEnterScanValue
-	// New Scan
-	case opts + 1:
-		console.mscan.Reset()
-		console.step = ConsoleStepSelectType
-	// Change All
-	case opts + 2:
+	case "Change All":
 		console.step = ConsoleStepEnterChangeValue
-	// Change Value
-	default:
-		console.selectIndex = i
+	case "Freeze All":
+		console.step = ConsoleStepEnterFreezeValue
+	case "History":
+		console.step = ConsoleStepSelectHistory
+	case "Unfreeze All":
+		console.mscan.UnfreezeAll()
+	}
+}
+
+// selectAction 选中单个结果之后的操作
+func (console *Console) selectAction() {
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "> {{ . | red }}",
+		Inactive: "  {{ . }}",
+	}
+
+	address := console.mscan.Results()[console.selectIndex]
+	items := []string{"Change Value", "Freeze", "Back"}
+	if console.mscan.IsFrozen(address) {
+		items[1] = "Unfreeze"
+	}
+
+	prompt := promptui.Select{
+		Label:     colorLabel.Sprintf("<%08X>", address),
+		Items:     items,
+		Templates: templates,
+	}
+	prompt.HideHelp = true
+
+	_, item, err := prompt.Run()
+	console.checkError(err)
+
+	fmt.Print("\u001B[1A\u001B[2K")
+
+	switch item {
+	case "Change Value":
 		console.step = ConsoleStepEnterChangeValue
+	case "Freeze":
+		console.step = ConsoleStepEnterFreezeValue
+	case "Unfreeze":
+		console.mscan.Unfreeze(address)
+		console.step = ConsoleStepNext
+	default:
+		console.step = ConsoleStepNext
 	}
 }
 
+// selectHistory 回到之前的某一轮, 最后一项为当前结果
+func (console *Console) selectHistory() {
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "> {{ . | red }}",
+		Inactive: "  {{ . }}",
+	}
+
+	history := console.mscan.History()
+	items := make([]string, len(history))
+	for i, entry := range history {
+		items[i] = fmt.Sprintf("#%d  %-32s %d", entry.Round, entry.Scan, entry.Count)
+		if entry.Canceled {
+			items[i] += " (canceled)"
+		}
+	}
+	items[len(items)-1] += " (current)"
+
+	prompt := promptui.Select{
+		Label:     colorLabel.Sprintf("<HISTORY>"),
+		Items:     items,
+		Templates: templates,
+		Size:      12,
+	}
+	prompt.HideHelp = true
+
+	i, _, err := prompt.RunCursorAt(len(items)-1, 0)
+	console.checkError(err)
+
+	fmt.Print("\u001B[1A\u001B[2K")
+
+	entry := history[i]
+	if i < len(history)-1 && console.mscan.UndoTo(entry.Round) {
+		// Bytes, String 每一轮的长度可能不同, 按该轮的值显示结果
+		if v := entry.Value; v != nil && !v.Type().Numeric() {
+			console.value = v.Clone()
+		}
+		console.lastScan = 0
+	}
+	console.step = ConsoleStepNext
+}
+
 func (console *Console) nextScan() {
-	console.lastScan = console.mscan.NextScanForceSparse(console.value)
+	if console.allTypes {
+		console.report(console.mscan.NextScanMulti(console.multi))
+	} else if console.compare.Relative() {
+		var operand *scanner.Value
+		if console.compare.NeedsOperand() {
+			operand = console.value
+		}
+		cmp, err := scanner.NewComparison(console.value.Type(), console.compare, operand)
+		console.checkError(err)
+		console.report(console.mscan.NextScanCompareForceSparse(cmp))
+	} else {
+		console.report(console.mscan.NextScanForceSparse(console.scanValue()))
+	}
 	console.step = ConsoleStepNext
 }
 
@@ -312,6 +723,10 @@ func runConsole() {
 		quit:  make(chan os.Signal, 1),
 	}
 
+	if imageFile != "" {
+		console.openImage(imageFile)
+	}
+
 	signal.Notify(console.quit, os.Interrupt)
 	go console.Run()
 	<-console.quit
@@ -320,25 +735,36 @@ func runConsole() {
 
 type inputError struct {
 	typ scanner.Type
+	enc scanner.Encoding
 }
 
 func (e *inputError) Error() string {
 	var help string
 	switch e.typ {
 	case scanner.Int8:
-		help = fmt.Sprintf("Int8: 8bit (%d to %d) or (0 to %d)", math.MinInt8, math.MaxInt8, math.MaxUint8)
+		help = fmt.Sprintf("Int8: 8bit (%d to %d)", math.MinInt8, math.MaxInt8)
 	case scanner.Int16:
-		help = fmt.Sprintf("Int16: 16bit (%d to %d) or (0 to %d)", math.MinInt16, math.MaxInt16, math.MaxUint16)
+		help = fmt.Sprintf("Int16: 16bit (%d to %d)", math.MinInt16, math.MaxInt16)
 	case scanner.Int32:
-		help = fmt.Sprintf("Int32: 32bit (%d to %d) or (0 to %d)", math.MinInt32, math.MaxInt32, math.MaxUint32)
+		help = fmt.Sprintf("Int32: 32bit (%d to %d)", math.MinInt32, math.MaxInt32)
 	case scanner.Int64:
-		help = fmt.Sprintf("Int64: 64bit (%d to %d) or (0 to %d)", math.MinInt64, math.MaxInt64, uint64(math.MaxUint64))
+		help = fmt.Sprintf("Int64: 64bit (%d to %d)", math.MinInt64, math.MaxInt64)
+	case scanner.Uint8:
+		help = fmt.Sprintf("Uint8: 8bit (0 to %d)", math.MaxUint8)
+	case scanner.Uint16:
+		help = fmt.Sprintf("Uint16: 16bit (0 to %d)", math.MaxUint16)
+	case scanner.Uint32:
+		help = fmt.Sprintf("Uint32: 32bit (0 to %d)", math.MaxUint32)
+	case scanner.Uint64:
+		help = fmt.Sprintf("Uint64: 64bit (0 to %d)", uint64(math.MaxUint64))
 	case scanner.Float32:
 		help = fmt.Sprintf("Float32: 32bit (max: %g)", math.MaxFloat32)
 	case scanner.Float64:
 		help = fmt.Sprintf("Float64: 64bit (max: %g)", math.MaxFloat64)
+	case scanner.String:
+		help = fmt.Sprintf("String: should be a non-empty %s text", e.enc)
 	case scanner.Bytes:
-		help = `Bytes: should be a hex string, e.g. "FF 01"`
+		help = `Bytes: should be a hex string or a pattern with wildcards, e.g. "FF 01" or "48 8B ?? ?? 4?"`
 	}
 	return fmt.Sprintf("invalid input. %s", help)
 }
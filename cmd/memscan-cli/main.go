@@ -16,6 +16,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -33,6 +34,8 @@ var (
 	customTest             bool
 	findGameWithInstanceID int
 	findGameWithAppID      int64
+	imageFile              string
+	dumpFile               string
 )
 
 func init() {
@@ -42,6 +45,8 @@ func init() {
 	pflag.BoolVarP(&customTest, "test", "t", false, "Test")
 	pflag.IntVar(&findGameWithInstanceID, "instance", 0, "find game process with instance ID")
 	pflag.Int64Var(&findGameWithAppID, "appid", -1, "find game process with app ID")
+	pflag.StringVar(&imageFile, "image", "", "scan a memory dump or ELF core file instead of a running game")
+	pflag.StringVar(&dumpFile, "dump", "", "dump the memory of a running game (--appid or the first one) to file")
 
 	// Execute the parsing
 	pflag.Parse()
@@ -51,6 +56,8 @@ func main() {
 	switch {
 	case customTest:
 		customTestFunc()
+	case dumpFile != "":
+		dumpGame()
 	case showAllProcesses:
 		processes, err := deck.EnumDeckProcesses()
 		checkError(err)
@@ -80,6 +87,32 @@ func checkError(err error) {
 	}
 }
 
+// dumpGame 保存可写的 Region, 之后可以使用 --image 离线扫描
+func dumpGame() {
+	var process *deck.Process
+	if findGameWithAppID > -1 {
+		process = deck.FindGameWithAppID(findGameWithAppID)
+	} else if processes := deck.EnumGameProcesses(); len(processes) > 0 {
+		process = processes[0]
+	}
+	if process == nil {
+		checkError(errors.New("no game is running"))
+	}
+
+	scan := memscan.NewMemscan()
+	checkError(scan.Open(process))
+	defer scan.Close()
+
+	f, err := os.Create(dumpFile)
+	checkError(err)
+	err = scan.DumpRegions(f, memscan.REGION_ALL_RW)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	checkError(err)
+	fmt.Printf("Dumped [%d] %s to %s\n", process.PID, process.Comm, dumpFile)
+}
+
 func customTestFunc() {
 	processes := deck.EnumGameProcesses()
 	if len(processes) == 0 {
@@ -98,15 +131,15 @@ func customTestFunc() {
 	process.Pause()
 	defer process.Resume()
 
-	dur := scan.FirstScan(value, true)
-	fmt.Printf("FirstScan: %d, %s\n", scan.Count(), dur)
+	report := scan.FirstScan(value, true)
+	fmt.Printf("FirstScan: %s\n", report)
 
-	dur = scan.NextScanForceDense(value)
-	fmt.Printf("NextScanForceDense: %d, %s\n", scan.Count(), dur)
+	report = scan.NextScanForceDense(value)
+	fmt.Printf("NextScanForceDense: %s\n", report)
 
 	scan.UndoScan()
 	fmt.Printf("Undo: %d\n", scan.Count())
 
-	dur = scan.NextScanForceSparse(value)
-	fmt.Printf("NextScanForceSparse: %d, %s\n", scan.Count(), dur)
+	report = scan.NextScanForceSparse(value)
+	fmt.Printf("NextScanForceSparse: %s\n", report)
 }
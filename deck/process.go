@@ -85,6 +85,9 @@ type Process struct {
 	State ProcessState
 
 	RSS uint64
+	// the time the process started after system boot, in clock ticks
+	// PID may be reused, PID and StartTime together identify a process instance
+	StartTime uint64
 }
 
 // Refresh read and parse /proc/pid/stat
@@ -113,6 +116,10 @@ func (proc *Process) Refresh() error {
 		if len(statParts) >= 24 {
 			proc.RSS, _ = strconv.ParseUint(string(statParts[23]), 10, 64)
 		}
+		// Comm may contain spaces, count fields after ')', starttime is field 22
+		if fields := bytes.Fields(buf[r+1:]); len(fields) >= 20 {
+			proc.StartTime, _ = strconv.ParseUint(string(fields[19]), 10, 64)
+		}
 
 		buf, err = os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", proc.PID))
 		if err == nil {
@@ -0,0 +1,289 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+const (
+	DefFreezeInterval = 100 * time.Millisecond
+	MinFreezeInterval = 10 * time.Millisecond
+)
+
+type FreezeMode uint8
+
+const (
+	FreezeAlways FreezeMode = iota
+	// FreezeBelow 仅当前值小于冻结值时写入, 例如 HP 不会减少但可以增加
+	FreezeBelow
+	// FreezeAbove 仅当前值大于冻结值时写入
+	FreezeAbove
+)
+
+func (mode FreezeMode) String() string {
+	switch mode {
+	case FreezeAlways:
+		return "Always"
+	case FreezeBelow:
+		return "Below"
+	case FreezeAbove:
+		return "Above"
+	}
+	return "Unknown"
+}
+
+// FreezeEntry 冻结的地址, Interval 为写入间隔
+type FreezeEntry struct {
+	Address  uint64
+	Value    *scanner.Value
+	Mode     FreezeMode
+	Interval time.Duration
+
+	next time.Time
+	// cond 非 FreezeAlways 时判断当前值是否需要写入
+	cond scanner.ValueComparable
+	buf  []byte
+}
+
+// freezer 在后台按每个地址自己的间隔重复写入
+// 与扫描结果无关, NextScan/Reset 不影响它, 进程退出时自动停止并清空
+type freezer struct {
+	mem     MemoryAccessor
+	mu      sync.Mutex
+	entries map[uint64]*FreezeEntry
+	wake    chan struct{}
+	stop    chan struct{}
+	running bool
+}
+
+func newFreezer(mem MemoryAccessor) *freezer {
+	return &freezer{
+		mem:     mem,
+		entries: make(map[uint64]*FreezeEntry),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+}
+
+func (f *freezer) set(address uint64, value *scanner.Value, mode FreezeMode, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefFreezeInterval
+	}
+	interval = max(interval, MinFreezeInterval)
+
+	v := value.Clone()
+	v.WithCompare(scanner.CompareEqual)
+	entry := &FreezeEntry{
+		Address:  address,
+		Value:    v,
+		Mode:     mode,
+		Interval: interval,
+	}
+	if mode != FreezeAlways && v.Type().Numeric() {
+		c := v.Clone()
+		if mode == FreezeBelow {
+			c.WithCompare(scanner.CompareLess)
+		} else {
+			c.WithCompare(scanner.CompareGreater)
+		}
+		entry.cond = c.Comparable()
+		entry.buf = make([]byte, v.Size())
+	}
+
+	f.mu.Lock()
+	f.entries[address] = entry
+	if !f.running {
+		f.running = true
+		go f.run()
+	}
+	f.mu.Unlock()
+
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (f *freezer) remove(address uint64) {
+	f.mu.Lock()
+	delete(f.entries, address)
+	f.mu.Unlock()
+}
+
+func (f *freezer) clear() {
+	f.mu.Lock()
+	clear(f.entries)
+	f.mu.Unlock()
+}
+
+func (f *freezer) has(address uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.entries[address]
+	return ok
+}
+
+// list 按地址排序
+func (f *freezer) list() []FreezeEntry {
+	f.mu.Lock()
+	entries := make([]FreezeEntry, 0, len(f.entries))
+	for _, entry := range f.entries {
+		entries = append(entries, FreezeEntry{
+			Address:  entry.Address,
+			Value:    entry.Value,
+			Mode:     entry.Mode,
+			Interval: entry.Interval,
+		})
+	}
+	f.mu.Unlock()
+	slices.SortFunc(entries, func(a, b FreezeEntry) int {
+		return cmp.Compare(a.Address, b.Address)
+	})
+	return entries
+}
+
+// close 停止后台写入, 之后不能再使用
+func (f *freezer) close() {
+	f.mu.Lock()
+	clear(f.entries)
+	f.mu.Unlock()
+	close(f.stop)
+}
+
+func (f *freezer) run() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-f.wake:
+		case <-timer.C:
+		}
+		wait, ok := f.tick()
+		if !ok {
+			return
+		}
+		timer.Reset(wait)
+	}
+}
+
+// tick 写入所有到期的地址, 返回距离下一次写入的时间
+// 没有冻结的地址或者进程已退出时返回 false
+func (f *freezer) tick() (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.entries) == 0 || !f.mem.Alive() {
+		clear(f.entries)
+		f.running = false
+		return 0, false
+	}
+
+	now := time.Now()
+	next := now.Add(time.Hour)
+	for _, entry := range f.entries {
+		if !entry.next.After(now) {
+			f.write(entry)
+			entry.next = now.Add(entry.Interval)
+		}
+		if entry.next.Before(next) {
+			next = entry.next
+		}
+	}
+	return next.Sub(now), true
+}
+
+// write 写入失败 (例如内存已释放) 时保留该地址, 由用户决定是否取消
+func (f *freezer) write(entry *FreezeEntry) {
+	if entry.cond != nil {
+		local := []unix.Iovec{{Base: &entry.buf[0], Len: uint64(len(entry.buf))}}
+		remote := []unix.RemoteIovec{{Base: uintptr(entry.Address), Len: len(entry.buf)}}
+		n, _ := f.mem.ReadV(local, remote)
+		if n != len(entry.buf) || !entry.cond.EqualBytes(entry.buf) {
+			return
+		}
+	}
+	data := entry.Value.Bytes()
+	local := []unix.Iovec{{Base: &data[0], Len: uint64(len(data))}}
+	remote := []unix.RemoteIovec{{Base: uintptr(entry.Address), Len: len(data)}}
+	_, _ = f.mem.WriteV(local, remote)
+}
+
+// Freeze 在后台按 interval 重复写入 value, interval <= 0 时使用 DefFreezeInterval
+// 同一地址再次冻结时替换原来的值
+func (m *Memscan) Freeze(address uint64, value *scanner.Value, mode FreezeMode, interval time.Duration) error {
+	if value == nil || value.Size() == 0 {
+		return ErrScanValue
+	}
+	if value.IsPattern() {
+		return ErrWritePattern
+	}
+	if m.freezer == nil || !m.alive() {
+		return ErrNoProcess
+	}
+	m.freezer.set(address, value, mode, interval)
+	return nil
+}
+
+// FreezeResults 与 ChangeResultsValues 相同, retIndexes 为空时冻结所有结果
+func (m *Memscan) FreezeResults(retIndexes []int, value *scanner.Value, mode FreezeMode, interval time.Duration) error {
+	if value == nil {
+		return ErrScanValue
+	}
+	if value.IsPattern() {
+		return ErrWritePattern
+	}
+	for _, address := range m.resultAddresses(retIndexes, value.Type()) {
+		if err := m.Freeze(address, value, mode, interval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memscan) Unfreeze(address uint64) {
+	if m.freezer != nil {
+		m.freezer.remove(address)
+	}
+}
+
+func (m *Memscan) UnfreezeAll() {
+	if m.freezer != nil {
+		m.freezer.clear()
+	}
+}
+
+func (m *Memscan) IsFrozen(address uint64) bool {
+	return m.freezer != nil && m.freezer.has(address)
+}
+
+// FrozenEntries 按地址排序
+func (m *Memscan) FrozenEntries() []FreezeEntry {
+	if m.freezer == nil {
+		return nil
+	}
+	return m.freezer.list()
+}
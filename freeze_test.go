@@ -0,0 +1,105 @@
+package memscan
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+func TestMemscan_Freeze(t *testing.T) {
+	const base = 0x10000
+	mem := NewFakeMemory("/fake/game")
+	if err := mem.Map(base, make([]byte, memPageSize), ParsePermissions("rw-p"), ""); err != nil {
+		t.Fatal(err)
+	}
+	// 通过 ReadV, WriteV 访问, 与后台写入互斥
+	get := func(address uint64) int32 {
+		var b [4]byte
+		local := []unix.Iovec{{Base: &b[0], Len: 4}}
+		remote := []unix.RemoteIovec{{Base: uintptr(address), Len: 4}}
+		if n, err := mem.ReadV(local, remote); n != 4 {
+			t.Fatalf("read %X: %v", address, err)
+		}
+		return int32(binary.LittleEndian.Uint32(b[:]))
+	}
+	set := func(address uint64, v int32) {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(v))
+		local := []unix.Iovec{{Base: &b[0], Len: 4}}
+		remote := []unix.RemoteIovec{{Base: uintptr(address), Len: 4}}
+		if n, err := mem.WriteV(local, remote); n != 4 {
+			t.Fatalf("write %X: %v", address, err)
+		}
+	}
+	wait := func(what string, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: timeout", what)
+			}
+		}
+	}
+	// idle 等待若干次写入的时间
+	idle := func() {
+		time.Sleep(5 * MinFreezeInterval)
+	}
+
+	m := NewMemscan()
+	if err := m.OpenAccessor(mem, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if err := m.Freeze(base, nil, FreezeAlways, 0); err != ErrScanValue {
+		t.Fatalf("nil value: got %v", err)
+	}
+
+	always, below, above := uint64(base), uint64(base+4), uint64(base+8)
+	for _, err := range []error{
+		m.Freeze(always, scanner.NewInt32(100), FreezeAlways, MinFreezeInterval),
+		m.Freeze(below, scanner.NewInt32(50), FreezeBelow, MinFreezeInterval),
+		m.Freeze(above, scanner.NewInt32(50), FreezeAbove, MinFreezeInterval),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wait("always", func() bool { return get(always) == 100 })
+	set(always, 5)
+	wait("rewrite", func() bool { return get(always) == 100 })
+
+	// below 为 0, above 为 0, 只有 below 满足条件
+	wait("below", func() bool { return get(below) == 50 })
+	set(below, 80)
+	idle()
+	if v := get(below); v != 80 {
+		t.Fatalf("below: %d written", v)
+	}
+	if v := get(above); v != 0 {
+		t.Fatalf("above: %d written", v)
+	}
+	set(above, 90)
+	wait("above", func() bool { return get(above) == 50 })
+
+	m.Unfreeze(always)
+	set(always, 7)
+	idle()
+	if v := get(always); v != 7 || m.IsFrozen(always) {
+		t.Fatalf("unfreeze: %d written", v)
+	}
+
+	f := m.freezer
+	mem.Kill()
+	wait("kill", func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return !f.running
+	})
+	if entries := m.FrozenEntries(); len(entries) != 0 {
+		t.Fatalf("after kill: %d entries", len(entries))
+	}
+}
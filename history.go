@@ -0,0 +1,253 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+const (
+	// DefHistoryLimit 默认保留的历史轮数, 不包括当前一轮
+	DefHistoryLimit = 8
+
+	// historySpareBuffers 丢弃的缓冲区最多保留一轮用于复用
+	historySpareBuffers = 3
+)
+
+// HistoryEntry 一轮扫描的条件和结果数量
+type HistoryEntry struct {
+	Round uint
+	Count int
+	// Scan 扫描条件的描述, 例如 "Int32 = 100", "Float32 increased"
+	Scan string
+	// Value FirstScan, NextScan 使用的值, 其它扫描为 nil
+	Value *scanner.Value
+	// Comparison NextScanCompare 与上一轮比较, 其它扫描为 nil
+	Comparison *scanner.Comparison
+	// Canceled 这一轮被取消, 结果不完整
+	Canceled bool
+}
+
+// scanRound 历史中的一轮结果
+type scanRound struct {
+	results  *MmapUint64
+	values   *MmapUint64
+	types    *MmapUint64
+	snapshot *Snapshot
+	step     HistoryEntry
+}
+
+func (r *scanRound) count() int {
+	if r.snapshot != nil {
+		return r.snapshot.Count()
+	}
+	return r.results.Len()
+}
+
+func valueStep(value *scanner.Value) HistoryEntry {
+	return HistoryEntry{Scan: describeValue(value), Value: value.Clone()}
+}
+
+func compareStep(cmp *scanner.Comparison) HistoryEntry {
+	return HistoryEntry{Scan: fmt.Sprintf("%s %s", cmp.Type(), cmp), Comparison: cmp}
+}
+
+func multiStep(multi *scanner.Multi) HistoryEntry {
+	return HistoryEntry{Scan: fmt.Sprintf("All numeric = %s", multi.Values()[0].Format())}
+}
+
+func groupStep(group *scanner.Group) HistoryEntry {
+	return HistoryEntry{Scan: fmt.Sprintf("Group %s", group)}
+}
+
+// describeValue 例如 "Int32 = 100", "Int32 between 1 and 10", "Bytes AA ?? BB"
+func describeValue(v *scanner.Value) string {
+	typ := v.Type()
+	switch {
+	case v.Compare() == scanner.CompareBetween:
+		return fmt.Sprintf("%s between %s and %s", typ, v.Format(), v.Upper().Format())
+	case v.HasCompare():
+		return fmt.Sprintf("%s %s %s", typ, v.Compare(), v.Format())
+	case typ == scanner.String:
+		return fmt.Sprintf("%s %q", typ, v.Text())
+	case typ == scanner.Bytes:
+		return fmt.Sprintf("%s %s", typ, v.String())
+	case v.HasOption():
+		return fmt.Sprintf("%s = %s (%s)", typ, v.Format(), v.Option())
+	}
+	return fmt.Sprintf("%s = %s", typ, v.Format())
+}
+
+// History 按轮次排列的历史, 最后一项为当前结果
+func (m *Memscan) History() []HistoryEntry {
+	if m.round == 0 {
+		return nil
+	}
+	entries := make([]HistoryEntry, 0, len(m.history)+1)
+	for _, r := range m.history {
+		entry := r.step
+		entry.Count = r.count()
+		entries = append(entries, entry)
+	}
+	entry := m.step
+	entry.Count = m.Count()
+	return append(entries, entry)
+}
+
+// SetHistoryLimit 最多保留 n 轮历史, n < 1 时为 1
+func (m *Memscan) SetHistoryLimit(n int) {
+	m.historyLimit = max(n, 1)
+	m.trimHistory()
+}
+
+func (m *Memscan) CanUndo() bool {
+	return len(m.history) > 0
+}
+
+// UndoScan 回到上一轮
+func (m *Memscan) UndoScan() bool {
+	if len(m.history) == 0 {
+		return false
+	}
+	return m.UndoTo(m.history[len(m.history)-1].step.Round)
+}
+
+// UndoTo 回到历史中的 round, 之后的轮次被丢弃
+func (m *Memscan) UndoTo(round uint) bool {
+	index := -1
+	for i, r := range m.history {
+		if r.step.Round == round {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return false
+	}
+
+	m.releaseRound(m.currentRound())
+	for _, r := range m.history[index+1:] {
+		m.releaseRound(r)
+	}
+	m.restoreRound(m.history[index])
+	// 恢复的值早于上一次清除 soft-dirty, 其间的写入没有标记
+	m.dirtyValid = false
+	clear(m.history[index:])
+	m.history = m.history[:index]
+	return true
+}
+
+func (m *Memscan) currentRound() *scanRound {
+	return &scanRound{
+		results:  m.results,
+		values:   m.values,
+		types:    m.types,
+		snapshot: m.snapshot,
+		step:     m.step,
+	}
+}
+
+func (m *Memscan) restoreRound(r *scanRound) {
+	m.results, m.values, m.types = r.results, r.values, r.types
+	m.snapshot = r.snapshot
+	m.step = r.step
+	m.round = r.step.Round
+}
+
+// pushRound 当前结果进入历史, 换上空的缓冲区作为新一轮
+// 尚未扫描 (round 0) 时没有需要保留的结果
+func (m *Memscan) pushRound() {
+	if m.round == 0 {
+		m.releaseRound(m.currentRound())
+	} else {
+		m.history = append(m.history, m.currentRound())
+		m.trimHistory()
+	}
+	m.results, m.values, m.types = m.takeBuffer(), m.takeBuffer(), m.takeBuffer()
+	m.snapshot = nil
+	m.step = HistoryEntry{}
+}
+
+func (m *Memscan) trimHistory() {
+	limit := m.historyLimit
+	if limit < 1 {
+		limit = DefHistoryLimit
+	}
+	if n := len(m.history) - limit; n > 0 {
+		m.dropHistory(n)
+	}
+}
+
+// dropHistory 丢弃最早的 n 轮
+func (m *Memscan) dropHistory(n int) {
+	for _, r := range m.history[:n] {
+		m.releaseRound(r)
+	}
+	m.history = append(m.history[:0], m.history[n:]...)
+	clear(m.history[len(m.history):cap(m.history)])
+}
+
+// clearHistory 丢弃所有历史, 不影响当前结果
+func (m *Memscan) clearHistory() {
+	m.dropHistory(len(m.history))
+}
+
+func (m *Memscan) releaseRound(r *scanRound) {
+	m.releaseBuffer(r.results)
+	m.releaseBuffer(r.values)
+	m.releaseBuffer(r.types)
+	if r.snapshot != nil {
+		r.snapshot.Destroy()
+	}
+}
+
+func (m *Memscan) releaseBuffer(buf *MmapUint64) {
+	if buf == nil {
+		return
+	}
+	if len(m.spare) < historySpareBuffers {
+		buf.Clear()
+		m.spare = append(m.spare, buf)
+		return
+	}
+	buf.Destroy()
+}
+
+// takeBuffer 优先复用丢弃的缓冲区, 内存不足时丢弃最早的历史
+func (m *Memscan) takeBuffer() *MmapUint64 {
+	for {
+		if n := len(m.spare); n > 0 {
+			buf := m.spare[n-1]
+			m.spare[n-1] = nil
+			m.spare = m.spare[:n-1]
+			buf.SetLimit(m.resultsLimit)
+			return buf
+		}
+		buf, err := m.newBuffer(resultsAllocCaps)
+		if err == nil {
+			buf.SetLimit(m.resultsLimit)
+			return buf
+		}
+		if len(m.history) == 0 {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			return nil
+		}
+		m.dropHistory(1)
+	}
+}
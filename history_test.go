@@ -0,0 +1,69 @@
+package memscan
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+// commitTestRound 模拟一轮 NextScan 的结果
+func commitTestRound(t *testing.T, m *Memscan, value *scanner.Value, addresses ...uint64) {
+	buf, err := NewMmapUint64(len(addresses) + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = buf.Put(addresses...)
+	m.regionBuffers = []*MmapUint64{buf}
+	m.commitResults(valueStep(value))
+}
+
+func TestMemscan_History(t *testing.T) {
+	m := NewMemscan()
+	m.Reset()
+	m.SetHistoryLimit(3)
+
+	for i := 1; i <= 5; i++ {
+		addresses := make([]uint64, 10-i)
+		for k := range addresses {
+			addresses[k] = uint64(0x1000 + k*4)
+		}
+		commitTestRound(t, m, scanner.NewInt32(int32(i)), addresses...)
+	}
+
+	history := m.History()
+	var rounds []uint
+	for _, entry := range history {
+		rounds = append(rounds, entry.Round)
+	}
+	if !slices.Equal(rounds, []uint{2, 3, 4, 5}) {
+		t.Fatalf("rounds: got %v", rounds)
+	}
+	if last := history[len(history)-1]; last.Count != 5 || last.Scan != "Int32 = 5" || last.Value == nil {
+		t.Errorf("current: got %+v", last)
+	}
+
+	if m.UndoTo(1) {
+		t.Error("round 1 should have been dropped")
+	}
+	if !m.UndoTo(3) || m.Rounds() != 3 || m.Count() != 7 || len(m.History()) != 2 {
+		t.Errorf("undo to 3: round %d, count %d", m.Rounds(), m.Count())
+	}
+	if !m.UndoScan() || m.Rounds() != 2 || m.Count() != 8 || m.CanUndo() {
+		t.Errorf("undo: round %d, count %d", m.Rounds(), m.Count())
+	}
+	if m.UndoScan() {
+		t.Error("nothing to undo")
+	}
+
+	// 撤销之后继续扫描
+	commitTestRound(t, m, scanner.NewInt32(6), 0x1000)
+	if m.Rounds() != 3 || m.Count() != 1 || !m.CanUndo() {
+		t.Errorf("next scan: round %d, count %d", m.Rounds(), m.Count())
+	}
+
+	m.Reset()
+	if m.History() != nil || m.CanUndo() {
+		t.Error("reset should clear history")
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// 内存快照文件 (.msdump) 不压缩, 打开时直接映射
+//
+//	"MSDP" version, 填充到 memPageSize
+//	data: Region 的数据, 按页对齐, 无法读取的页面以 0 填充
+//	index: exe, maps, regions: count, {start, size, perm, type, filename, baseAddr, offset, invalid: count, {uint64}}
+//	footer: index 的偏移 (uint64 little-endian), "MSDP"
+//
+// maps 为保存时完整的 /proc/pid/maps, 打开后 Maps.Parse 的结果与保存时相同
+// invalid 为无效页面的位图, count 为 0 时全部有效
+const (
+	imageMagic   = "MSDP"
+	imageFileVer = 1
+
+	imageFooterSize  = 8 + len(imageMagic)
+	imageMaxMapsSize = 64 << 20
+	imageMaxCount    = 1 << 32
+)
+
+var ErrImageFile = errors.New("invalid memory image")
+
+// MemoryImage 只读的离线目标, 来自 DumpRegions 保存的快照或 ELF core 文件
+// 未保存的页面读取时返回 EFAULT, 写入总是返回 EROFS
+type MemoryImage struct {
+	mu sync.RWMutex
+	// data 整个文件的映射, Close 之后为 nil
+	data []byte
+	// regions 按 Start 排列, 互不重叠
+	regions []imageRegion
+	maps    []byte
+	exe     string
+}
+
+type imageRegion struct {
+	Region
+	// data 可能短于 Size, 之后的部分无效 (ELF core 未保存的段)
+	data []byte
+	// invalid 无效页面的位图, nil 表示全部有效
+	invalid []uint64
+}
+
+func (r *imageRegion) valid(offset uint64) bool {
+	if offset >= uint64(len(r.data)) {
+		return false
+	}
+	page := offset / memPageSize
+	return r.invalid == nil || r.invalid[page/64]&(1<<(page%64)) == 0
+}
+
+// OpenImage 打开 DumpRegions 保存的快照或 ELF core 文件
+func OpenImage(path string) (*MemoryImage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() < int64(len(imageMagic)) {
+		return nil, ErrImageFile
+	}
+	data, err := unix.Mmap(int(f.Fd()), 0, int(stat.Size()), unix.PROT_READ, unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &MemoryImage{data: data}
+	if bytes.HasPrefix(data, []byte(imageMagic)) {
+		err = img.parseDump()
+	} else {
+		err = img.parseCore()
+	}
+	if err != nil {
+		_ = unix.Munmap(data)
+		return nil, err
+	}
+	return img, nil
+}
+
+func (img *MemoryImage) parseDump() error {
+	data := img.data
+	if len(data) < memPageSize+imageFooterSize || data[len(imageMagic)] != imageFileVer ||
+		string(data[len(data)-len(imageMagic):]) != imageMagic {
+		return ErrImageFile
+	}
+	indexOffset := binary.LittleEndian.Uint64(data[len(data)-imageFooterSize:])
+	if indexOffset < memPageSize || indexOffset > uint64(len(data)-imageFooterSize) {
+		return ErrImageFile
+	}
+
+	pr := &pointerReader{r: bufio.NewReader(bytes.NewReader(data[indexOffset : len(data)-imageFooterSize]))}
+	img.exe = pr.string()
+	img.maps = readBytes(pr, imageMaxMapsSize)
+	n := pr.uvarint()
+	if n > imageMaxCount {
+		pr.fail()
+	}
+	for i := uint64(0); i < n && pr.err == nil; i++ {
+		r := imageRegion{}
+		r.Start = pr.uvarint()
+		r.Size = pr.uvarint()
+		r.End = r.Start + r.Size
+		r.Perm = Permissions(pr.uvarint())
+		r.Type = RegionType(pr.uvarint())
+		r.Filename = pr.string()
+		r.BaseAddr = pr.uvarint()
+		offset := pr.uvarint()
+		if words := pr.uvarint(); words > 0 {
+			if words != imagePageWords(r.Size) {
+				pr.fail()
+				break
+			}
+			r.invalid = make([]uint64, words)
+			for k := range r.invalid {
+				r.invalid[k] = pr.uvarint()
+			}
+		}
+		if pr.err != nil || r.End < r.Start || offset < memPageSize || offset > indexOffset || r.Size > indexOffset-offset {
+			pr.fail()
+			break
+		}
+		r.data = data[offset : offset+r.Size]
+		img.regions = append(img.regions, r)
+	}
+	if pr.err != nil {
+		return ErrImageFile
+	}
+	return img.sortRegions()
+}
+
+// sortRegions 按 Start 排列并检查是否重叠
+func (img *MemoryImage) sortRegions() error {
+	slices.SortFunc(img.regions, func(a, b imageRegion) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	for i := 1; i < len(img.regions); i++ {
+		if img.regions[i].Start < img.regions[i-1].End {
+			return ErrImageFile
+		}
+	}
+	return nil
+}
+
+func (img *MemoryImage) Close() error {
+	img.mu.Lock()
+	defer img.mu.Unlock()
+
+	if img.data == nil {
+		return nil
+	}
+	err := unix.Munmap(img.data)
+	img.data = nil
+	img.regions = nil
+	return err
+}
+
+// Regions 保存的 Region 及其类型, 模块基址
+func (img *MemoryImage) Regions() []Region {
+	img.mu.RLock()
+	defer img.mu.RUnlock()
+
+	regions := make([]Region, len(img.regions))
+	for i, r := range img.regions {
+		regions[i] = r.Region
+	}
+	return regions
+}
+
+func (img *MemoryImage) ReadV(local []unix.Iovec, remote []unix.RemoteIovec) (int, error) {
+	img.mu.RLock()
+	defer img.mu.RUnlock()
+
+	if img.data == nil {
+		return 0, unix.ESRCH
+	}
+	return transferV(local, remote, func(buf []byte, addr uint64) (int, error) {
+		return img.read(buf, addr), nil
+	})
+}
+
+func (img *MemoryImage) WriteV(_ []unix.Iovec, _ []unix.RemoteIovec) (int, error) {
+	return 0, unix.EROFS
+}
+
+// read 从 addr 开始读取, 可以跨越相邻的 Region, 遇到无效页面时停止
+func (img *MemoryImage) read(buf []byte, addr uint64) (n int) {
+	for n < len(buf) {
+		address := addr + uint64(n)
+		i, found := slices.BinarySearchFunc(img.regions, address, func(r imageRegion, addr uint64) int {
+			return cmp.Compare(r.Start, addr)
+		})
+		if !found {
+			i--
+		}
+		if i < 0 || address >= img.regions[i].End {
+			break
+		}
+		r := &img.regions[i]
+		offset := address - r.Start
+		end := offset
+		for end < offset+uint64(len(buf)-n) && r.valid(end) {
+			end = (end + memPageSize) &^ (memPageSize - 1)
+		}
+		if end == offset {
+			break
+		}
+		n += copy(buf[n:], r.data[offset:min(end, uint64(len(r.data)))])
+	}
+	return
+}
+
+func (img *MemoryImage) OpenMaps() (io.ReadSeekCloser, error) {
+	if !img.Alive() {
+		return nil, unix.ESRCH
+	}
+	return staticMaps{bytes.NewReader(img.maps)}, nil
+}
+
+func (img *MemoryImage) Exe() string {
+	return img.exe
+}
+
+// Alive Close 之前总是 true
+func (img *MemoryImage) Alive() bool {
+	img.mu.RLock()
+	defer img.mu.RUnlock()
+	return img.data != nil
+}
+
+// imagePageWords 每页 1 位的位图需要的 uint64 数量
+func imagePageWords(size uint64) uint64 {
+	return (size + memPageSize*64 - 1) / (memPageSize * 64)
+}
+
+type staticMaps struct {
+	*bytes.Reader
+}
+
+func (staticMaps) Close() error {
+	return nil
+}
+
+// DumpRegions 将 Maps.Parse(level) 选择的 Region 保存为快照文件, 之后可以使用 OpenImage 离线扫描
+// 无法读取的页面以 0 填充并标记为无效
+func (m *Memscan) DumpRegions(w io.Writer, level RegionScanLevel, args ...bool) error {
+	if m.maps == nil || !m.alive() {
+		return ErrNoProcess
+	}
+
+	var processPaused bool
+	if len(args) > 0 {
+		processPaused = args[0]
+	}
+
+	if !processPaused && m.proc != nil {
+		m.proc.Pause()
+		defer m.proc.Resume()
+	}
+
+	maps, err := m.maps.text()
+	if err != nil {
+		return err
+	}
+	regions := m.maps.Parse(level)
+
+	pw := &pointerWriter{w: bufio.NewWriterSize(w, scanBufferSize)}
+	zero := make([]byte, memPageSize)
+	pw.bytes([]byte(imageMagic))
+	pw.bytes([]byte{imageFileVer})
+	pw.bytes(zero[:memPageSize-len(imageMagic)-1])
+
+	buf := getScanBuffer()
+	defer freeScanBuffer(buf)
+
+	offsets := make([]uint64, len(regions))
+	invalids := make([][]uint64, len(regions))
+	offset := uint64(memPageSize)
+	for i, region := range regions {
+		var invalid []uint64
+		pos := region.Start
+		// fill 以 0 填充 [pos, end) 并标记为无效
+		fill := func(end uint64) {
+			for pos < end {
+				if invalid == nil {
+					invalid = make([]uint64, imagePageWords(region.Size))
+				}
+				page := (pos - region.Start) / memPageSize
+				invalid[page/64] |= 1 << (page % 64)
+				n := min(end-pos, memPageSize-(pos-region.Start)%memPageSize)
+				pw.bytes(zero[:n])
+				pos += n
+			}
+		}
+		_, err = readRegion(m.mem, region.Start, region.End, buf, func(addr uint64, data []byte) bool {
+			fill(addr)
+			pw.bytes(data)
+			pos = addr + uint64(len(data))
+			return pw.err == nil
+		})
+		if errors.Is(err, unix.ESRCH) {
+			return err
+		}
+		fill(region.End)
+		if pw.err != nil {
+			return pw.err
+		}
+
+		offsets[i], invalids[i] = offset, invalid
+		offset += region.Size
+		if pad := -region.Size & (memPageSize - 1); pad > 0 {
+			pw.bytes(zero[:pad])
+			offset += pad
+		}
+	}
+
+	pw.string(m.mem.Exe())
+	pw.uvarint(uint64(len(maps)))
+	pw.bytes(maps)
+	pw.uvarint(uint64(len(regions)))
+	for i, region := range regions {
+		pw.uvarint(region.Start)
+		pw.uvarint(region.Size)
+		pw.uvarint(uint64(region.Perm))
+		pw.uvarint(uint64(region.Type))
+		pw.string(region.Filename)
+		pw.uvarint(region.BaseAddr)
+		pw.uvarint(offsets[i])
+		pw.uvarint(uint64(len(invalids[i])))
+		for _, word := range invalids[i] {
+			pw.uvarint(word)
+		}
+	}
+	var footer [imageFooterSize]byte
+	binary.LittleEndian.PutUint64(footer[:8], offset)
+	copy(footer[8:], imageMagic)
+	pw.bytes(footer[:])
+
+	if pw.err == nil {
+		pw.err = pw.w.Flush()
+	}
+	if pw.err != nil {
+		return fmt.Errorf("dump regions: %w", pw.err)
+	}
+	return nil
+}
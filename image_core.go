@@ -0,0 +1,205 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ntFile 文件映射的列表, debug/elf 中没有定义
+	ntFile = 0x46494c45
+
+	// prpsinfoFname elf_prpsinfo.pr_fname 在 x86_64 上的偏移和长度
+	prpsinfoFname    = 40
+	prpsinfoFnameLen = 16
+)
+
+// coreFile NT_FILE 中的一项
+type coreFile struct {
+	start, end, offset uint64
+	filename           string
+}
+
+// parseCore 仅支持 64 位 little-endian 的 core 文件
+// PT_LOAD 作为 Region, 文件名和偏移来自 NT_FILE, 主程序由 NT_PRPSINFO 的进程名识别
+// Filesz 小于 Memsz 的部分 (例如未保存的代码段) 读取时返回 EFAULT
+func (img *MemoryImage) parseCore() error {
+	f, err := elf.NewFile(bytes.NewReader(img.data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrImageFile, err)
+	}
+	if f.Type != elf.ET_CORE || f.Class != elf.ELFCLASS64 || f.Data != elf.ELFDATA2LSB {
+		return fmt.Errorf("%w: not a 64-bit little-endian core file", ErrImageFile)
+	}
+
+	var files []coreFile
+	var comm string
+	for _, prog := range f.Progs {
+		if prog.Type != elf.PT_NOTE {
+			continue
+		}
+		notes, ok := img.segment(prog.Off, prog.Filesz)
+		if !ok {
+			return ErrImageFile
+		}
+		eachCoreNote(notes, func(typ uint32, name string, desc []byte) {
+			if name != "CORE" {
+				return
+			}
+			switch {
+			case typ == ntFile:
+				files = parseCoreFiles(desc)
+			case typ == uint32(elf.NT_PRPSINFO) && len(desc) >= prpsinfoFname+prpsinfoFnameLen:
+				comm = string(bytes.TrimRight(desc[prpsinfoFname:prpsinfoFname+prpsinfoFnameLen], "\x00"))
+			}
+		})
+	}
+
+	var maps bytes.Buffer
+	for _, prog := range f.Progs {
+		if prog.Type != elf.PT_LOAD || prog.Memsz == 0 {
+			continue
+		}
+		r := imageRegion{}
+		r.Start = prog.Vaddr
+		r.Size = prog.Memsz
+		r.End = r.Start + r.Size
+		r.Perm = PermPrivate
+		if prog.Flags&elf.PF_R != 0 {
+			r.Perm |= PermRead
+		}
+		if prog.Flags&elf.PF_W != 0 {
+			r.Perm |= PermWrite
+		}
+		if prog.Flags&elf.PF_X != 0 {
+			r.Perm |= PermExec
+		}
+		var offset uint64
+		for _, file := range files {
+			if file.start == r.Start {
+				r.Filename, offset = file.filename, file.offset
+				break
+			}
+		}
+		data, ok := img.segment(prog.Off, min(prog.Filesz, prog.Memsz))
+		if !ok {
+			return ErrImageFile
+		}
+		r.data = data
+		img.regions = append(img.regions, r)
+		_, _ = fmt.Fprintf(&maps, "%x-%x %s %08x 00:00 0 %s\n", r.Start, r.End, r.Perm, offset, r.Filename)
+	}
+	img.maps = maps.Bytes()
+	img.exe = coreExe(files, comm)
+
+	if err = img.sortRegions(); err != nil {
+		return err
+	}
+	img.classify()
+	return nil
+}
+
+// segment 文件中 [offset, offset+size) 的数据
+func (img *MemoryImage) segment(offset, size uint64) ([]byte, bool) {
+	if offset > uint64(len(img.data)) || size > uint64(len(img.data))-offset {
+		return nil, false
+	}
+	return img.data[offset : offset+size], true
+}
+
+// classify 与 Maps.Parse 相同的方式确定每个 Region 的类型和模块基址
+func (img *MemoryImage) classify() {
+	maps := &Maps{exe: img.exe, file: staticMaps{bytes.NewReader(img.maps)}}
+	parsed := maps.Parse(REGION_ALL)
+	k := 0
+	for i := range img.regions {
+		r := &img.regions[i]
+		for k < len(parsed) && parsed[k].Start < r.Start {
+			k++
+		}
+		if k < len(parsed) && parsed[k].Start == r.Start {
+			r.Type, r.BaseAddr = parsed[k].Type, parsed[k].BaseAddr
+		}
+	}
+}
+
+// eachCoreNote 遍历 PT_NOTE 段中的 note, name 和 desc 按 4 字节对齐
+func eachCoreNote(b []byte, fn func(typ uint32, name string, desc []byte)) {
+	for len(b) >= 12 {
+		nameSize := uint64(binary.LittleEndian.Uint32(b))
+		descSize := uint64(binary.LittleEndian.Uint32(b[4:]))
+		typ := binary.LittleEndian.Uint32(b[8:])
+		b = b[12:]
+
+		descStart := (nameSize + 3) &^ 3
+		descEnd := descStart + descSize
+		if descEnd > uint64(len(b)) {
+			return
+		}
+		fn(typ, string(bytes.TrimRight(b[:nameSize], "\x00")), b[descStart:descEnd])
+		b = b[min((descEnd+3)&^3, uint64(len(b))):]
+	}
+}
+
+// parseCoreFiles NT_FILE: count, pageSize, count * {start, end, offset (页)}, count * 文件名
+func parseCoreFiles(desc []byte) []coreFile {
+	if len(desc) < 16 {
+		return nil
+	}
+	count := binary.LittleEndian.Uint64(desc)
+	pageSize := binary.LittleEndian.Uint64(desc[8:])
+	desc = desc[16:]
+	if count > uint64(len(desc))/24 {
+		return nil
+	}
+	names := bytes.Split(desc[count*24:], []byte{0})
+	if uint64(len(names)) < count {
+		return nil
+	}
+
+	files := make([]coreFile, count)
+	for i := range files {
+		entry := desc[i*24:]
+		files[i] = coreFile{
+			start:    binary.LittleEndian.Uint64(entry),
+			end:      binary.LittleEndian.Uint64(entry[8:]),
+			offset:   binary.LittleEndian.Uint64(entry[16:]) * pageSize,
+			filename: string(names[i]),
+		}
+	}
+	return files
+}
+
+// coreExe comm 最多 15 个字符, 与文件名的前缀比较, 找不到时使用第一个文件
+func coreExe(files []coreFile, comm string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	if comm != "" {
+		for _, file := range files {
+			if strings.HasPrefix(filepath.Base(file.filename), comm) {
+				return file.filename
+			}
+		}
+	}
+	return files[0].filename
+}
@@ -0,0 +1,187 @@
+package memscan
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+// phantomMemory Region 列表中多出一个没有映射的 Region, 读取时返回 EFAULT
+type phantomMemory struct {
+	*FakeMemory
+	extra string
+}
+
+func (p phantomMemory) OpenMaps() (io.ReadSeekCloser, error) {
+	return staticMaps{bytes.NewReader(append(p.render(), p.extra...))}, nil
+}
+
+func TestMemscan_DumpRegions(t *testing.T) {
+	fake := NewFakeMemory("/fake/game")
+	heap := make([]byte, 2*memPageSize)
+	exe := make([]byte, memPageSize)
+	binary.LittleEndian.PutUint32(heap[0x10:], 1234)
+	binary.LittleEndian.PutUint32(heap[0x1040:], 1234)
+	binary.LittleEndian.PutUint32(exe[0x8:], 1234)
+	rw := ParsePermissions("rw-p")
+	if err := fake.Map(0x10000, heap, rw, "[heap]"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.Map(0x20000, exe, rw, "/fake/game"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMemscan()
+	if err := m.OpenAccessor(phantomMemory{fake, "50000-52000 rw-p 00000000 00:00 0\n"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	value := scanner.NewInt32(1234)
+	if report := m.FirstScan(value); report.Err != nil || report.FailedRegions != 1 {
+		t.Fatalf("live scan: %+v", report)
+	}
+	want := slices.Sorted(slices.Values(m.Results()))
+
+	path := filepath.Join(t.TempDir(), "game.msdump")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.DumpRegions(f, REGION_ALL_RW); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+	_ = m.Close()
+
+	img, err := OpenImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer img.Close()
+	if regions := img.Regions(); len(regions) != 3 || regions[0].Type != REGION_TYPE_HEAP {
+		t.Fatalf("regions: %v", regions)
+	}
+
+	offline := NewMemscan()
+	if err = offline.OpenAccessor(img, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer offline.Close()
+
+	report := offline.FirstScan(value)
+	if report.Err != nil || report.FailedRegions != 1 || report.Failures[0].Start != 0x50000 {
+		t.Fatalf("offline scan: %+v", report)
+	}
+	if got := slices.Sorted(slices.Values(offline.Results())); !slices.Equal(got, want) {
+		t.Fatalf("offline results: got %X, want %X", got, want)
+	}
+	if report = offline.NextScan(value); report.Matches != len(want) {
+		t.Fatalf("offline next scan: %+v", report)
+	}
+	for _, row := range offline.RenderResults(value) {
+		if row[1] != "1234" {
+			t.Fatalf("render: %v", row)
+		}
+	}
+	if _, err = offline.WritePatch(want[0], []byte{0}); !errors.Is(err, unix.EROFS) {
+		t.Fatalf("write: got %v", err)
+	}
+}
+
+func TestOpenImage_Core(t *testing.T) {
+	// NT_PRPSINFO 只需要 pr_fname
+	prpsinfo := make([]byte, 136)
+	copy(prpsinfo[prpsinfoFname:], "game")
+	// NT_FILE: 一个文件映射
+	files := binary.LittleEndian.AppendUint64(nil, 1)
+	files = binary.LittleEndian.AppendUint64(files, memPageSize)
+	files = binary.LittleEndian.AppendUint64(files, 0x400000)
+	files = binary.LittleEndian.AppendUint64(files, 0x401000)
+	files = binary.LittleEndian.AppendUint64(files, 0)
+	files = append(files, "/opt/game\x00"...)
+
+	var notes []byte
+	for _, note := range []struct {
+		typ  uint32
+		desc []byte
+	}{{uint32(elf.NT_PRPSINFO), prpsinfo}, {ntFile, files}} {
+		notes = binary.LittleEndian.AppendUint32(notes, 5)
+		notes = binary.LittleEndian.AppendUint32(notes, uint32(len(note.desc)))
+		notes = binary.LittleEndian.AppendUint32(notes, note.typ)
+		notes = append(notes, "CORE\x00\x00\x00\x00"...)
+		notes = append(notes, note.desc...)
+		for len(notes)%4 != 0 {
+			notes = append(notes, 0)
+		}
+	}
+
+	const headerSize, progSize = 64, 56
+	notesOff := uint64(headerSize + 3*progSize)
+	dataOff := uint64(memPageSize)
+	progs := []elf.Prog64{
+		{Type: uint32(elf.PT_NOTE), Off: notesOff, Filesz: uint64(len(notes))},
+		// 代码段没有保存
+		{Type: uint32(elf.PT_LOAD), Flags: uint32(elf.PF_R | elf.PF_X), Vaddr: 0x400000, Memsz: memPageSize},
+		{Type: uint32(elf.PT_LOAD), Flags: uint32(elf.PF_R | elf.PF_W), Off: dataOff, Vaddr: 0x600000, Filesz: memPageSize, Memsz: 2 * memPageSize},
+	}
+	header := elf.Header64{
+		Type:      uint16(elf.ET_CORE),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Phoff:     headerSize,
+		Ehsize:    headerSize,
+		Phentsize: progSize,
+		Phnum:     uint16(len(progs)),
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, header)
+	_ = binary.Write(&buf, binary.LittleEndian, progs)
+	buf.Write(notes)
+	data := make([]byte, int(dataOff)-buf.Len()+memPageSize)
+	binary.LittleEndian.PutUint64(data[len(data)-memPageSize+0x20:], 0x0123456789ABCDEF)
+	buf.Write(data)
+
+	path := filepath.Join(t.TempDir(), "core")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	img, err := OpenImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer img.Close()
+	if img.Exe() != "/opt/game" {
+		t.Fatalf("exe: %q", img.Exe())
+	}
+	if regions := img.Regions(); len(regions) != 2 || regions[0].Filename != "/opt/game" || regions[0].Type != REGION_TYPE_EXE {
+		t.Fatalf("regions: %v", regions)
+	}
+
+	m := NewMemscan()
+	if err = m.OpenAccessor(img, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	report := m.FirstScan(scanner.NewInt64(0x0123456789ABCDEF))
+	if report.Err != nil || !slices.Equal(m.Results(), []uint64{0x600020}) {
+		t.Fatalf("core scan: %+v %X", report, m.Results())
+	}
+	// Filesz 之后的页面没有保存
+	if report.FailedRegions != 1 || report.Failures[0].Read != memPageSize {
+		t.Fatalf("core failures: %+v", report.Failures)
+	}
+}
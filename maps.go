@@ -2,9 +2,7 @@ package memscan
 
 import (
 	"bufio"
-	"fmt"
 	"io"
-	"os"
 	"strings"
 )
 
@@ -42,24 +40,47 @@ func isSkipRegion(filename string) bool {
 }
 
 func OpenMaps(pid int) (*Maps, error) {
-	f, err := os.Open(fmt.Sprintf("/proc/%d/maps", pid))
+	return NewMaps(NewProcessVM(pid))
+}
+
+// NewMaps 从 mem 读取 Region 列表
+func NewMaps(mem MemoryAccessor) (*Maps, error) {
+	f, err := mem.OpenMaps()
 	if err != nil {
 		return nil, err
 	}
-	exePath, _ := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
-	return &Maps{pid: pid, exe: exePath, file: f}, nil
+	return &Maps{exe: mem.Exe(), file: f}, nil
 }
 
 type Maps struct {
-	pid  int
 	exe  string
-	file *os.File
+	file io.ReadSeekCloser
 }
 
 func (m *Maps) Close() error {
 	return m.file.Close()
 }
 
+// text 完整的 Region 列表
+func (m *Maps) text() ([]byte, error) {
+	if _, err := m.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(m.file)
+}
+
+// all 未经过滤的全部 Region, 按地址排列
+func (m *Maps) all() (regions Regions) {
+	_, _ = m.file.Seek(0, io.SeekStart)
+	scan := bufio.NewScanner(m.file)
+	for scan.Scan() {
+		if r := ParseRegion(scan.Bytes()); r != nil {
+			regions = append(regions, *r)
+		}
+	}
+	return
+}
+
 func (m *Maps) Parse(args ...RegionScanLevel) (regions Regions) {
 	var scanLevel = REGION_ALL
 	if len(args) > 0 && args[0] <= REGION_HEAP_STACK_EXECUTABLE_BSS {
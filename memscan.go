@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"sync/atomic"
 
 	"golang.org/x/sync/semaphore"
 	"golang.org/x/sys/unix"
@@ -33,8 +34,10 @@ const (
 	IOV_MAX           = 1024
 	scanMaxGoroutines = 2048
 
+	// resultsAllocCaps 结果的初始容量, 超出时自动扩容
 	resultsAllocCaps = 64 * 1024 * 1024
 
+	// regionResultsAllocCaps 每个 Region 结果的初始容量, 超出时自动扩容
 	regionResultsAllocCaps = regionLargeSize / 8
 	collectBatchSize       = 512
 )
@@ -45,52 +48,96 @@ func NewMemscan() *Memscan {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 	}
 
-	lastResults, err := NewMmapUint64(resultsAllocCaps)
+	values, err := NewMmapUint64(resultsAllocCaps)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+	}
+
+	types, err := NewMmapUint64(resultsAllocCaps)
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 	}
 
 	return &Memscan{
-		results:     results,
-		prevResults: lastResults,
-		sem:         semaphore.NewWeighted(scanMaxGoroutines),
+		results:      results,
+		values:       values,
+		types:        types,
+		historyLimit: DefHistoryLimit,
+		sem:          semaphore.NewWeighted(scanMaxGoroutines),
 	}
 }
 
 type Memscan struct {
+	// proc 为 nil 时 (例如 FakeMemory) 扫描时不暂停进程, 也不能保存会话
 	proc          *deck.Process
+	mem           MemoryAccessor
 	maps          *Maps
 	results       *MmapUint64
-	prevResults   *MmapUint64
 	regionBuffers []*MmapUint64
 
-	round   uint
-	canUndo bool
-	sem     *semaphore.Weighted
-	ctx     context.Context
-	cancel  context.CancelFunc
+	// values 与 results 一一对应, 保存本轮结束时每个地址的原始值, 用于与上一轮比较
+	// 仅当 values.Len() == results.Len() 时有效
+	values       *MmapUint64
+	regionValues []*MmapUint64
+
+	// types 与 results 一一对应, 保存搜索所有数值类型时每个地址匹配的类型
+	// 仅当 types.Len() == results.Len() 时有效
+	types       *MmapUint64
+	regionTypes []*MmapUint64
+
+	// 未知初始值扫描后, 结果由快照隐式表示
+	snapshot  *Snapshot
+	valueType scanner.Type
+
+	// step 当前一轮的扫描条件
+	step HistoryEntry
+	// history 之前的轮次, 按轮次排列, 最多 historyLimit 轮
+	history      []*scanRound
+	historyLimit int
+	// spare 丢弃的缓冲区, 用于下一轮
+	spare []*MmapUint64
+	// resultsLimit 每一轮最多保存的结果数量, 0 表示不限制
+	resultsLimit int
+	// store 不为 nil 时结果映射到磁盘上的临时文件, 见 SetResultsStorage
+	store *resultStore
+
+	// freezer 属于进程, 不随扫描结果重置, Close 时停止
+	freezer *freezer
+	// patcher 与 freezer 相同, 保存强制写入的原始数据
+	patcher *patcher
+	// forceWrites 见 SetForceWrites
+	forceWrites bool
+
+	// dirtyTracking 见 SetDirtyTracking
+	// dirtyValid 当前一轮读取内存之前清除过 soft-dirty, 之后的写入都有标记
+	dirtyTracking bool
+	dirtyValid    bool
+
+	// progress 当前扫描的进度, 可以在其它 goroutine 中读取
+	progress     atomic.Pointer[scanProgress]
+	progressFunc ProgressFunc
+
+	round  uint
+	sem    *semaphore.Weighted
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func (m *Memscan) CanUndo() bool {
-	return m.canUndo
+// Open 使用 process_vm_readv/writev 访问 proc
+func (m *Memscan) Open(proc *deck.Process) error {
+	return m.OpenAccessor(NewProcessVM(proc.PID), proc)
 }
 
-func (m *Memscan) UndoScan() bool {
-	if !m.canUndo {
-		return false
-	}
-	m.canUndo = false
-	m.results, m.prevResults = m.prevResults, m.results
-	m.prevResults.Clear()
-	m.round -= 1
-	return true
-}
-
-func (m *Memscan) Open(proc *deck.Process) (err error) {
+// OpenAccessor 通过 mem 访问内存, proc 可以为 nil
+// mem 由调用者关闭 (例如 ProcMem), 必须在 Close 之后
+func (m *Memscan) OpenAccessor(mem MemoryAccessor, proc *deck.Process) (err error) {
 	_ = m.Close()
 
 	m.proc = proc
-	m.maps, err = OpenMaps(proc.PID)
+	m.mem = mem
+	m.freezer = newFreezer(mem)
+	m.patcher = newPatcher(mem)
+	m.maps, err = NewMaps(mem)
 	if err != nil {
 		return
 	}
@@ -98,13 +145,62 @@ func (m *Memscan) Open(proc *deck.Process) (err error) {
 	return nil
 }
 
+// Process 当前打开的进程, 未打开或者没有对应的进程时返回 nil
+func (m *Memscan) Process() *deck.Process {
+	return m.proc
+}
+
+// Accessor 当前使用的 MemoryAccessor, 未打开时返回 nil
+func (m *Memscan) Accessor() MemoryAccessor {
+	return m.mem
+}
+
+// alive 目标是否仍然可以访问
+func (m *Memscan) alive() bool {
+	return m.mem != nil && m.mem.Alive()
+}
+
 func (m *Memscan) Cancel() {
 	if m.cancel != nil {
 		m.cancel()
 	}
 }
 
+// bindContext 本次扫描同时受 ctx 和 Cancel 控制, 返回的函数恢复 m.ctx
+// 扫描结束之前必须调用, 通常使用 defer
+func (m *Memscan) bindContext(ctx context.Context) func() {
+	if ctx == nil || ctx.Done() == nil {
+		return func() {}
+	}
+	base := m.ctx
+	parent := base
+	if parent == nil {
+		parent = context.Background()
+	}
+	scanCtx, cancel := context.WithCancel(parent)
+	// AfterFunc 在其它 goroutine 中执行, 已经取消的 ctx 需要立即生效
+	if ctx.Err() != nil {
+		cancel()
+	}
+	stop := context.AfterFunc(ctx, cancel)
+	m.ctx = scanCtx
+	return func() {
+		stop()
+		cancel()
+		m.ctx = base
+	}
+}
+
+// Close 停止冻结, 关闭强制写入使用的 /proc/pid/mem, 之后的 Patch 无法恢复
 func (m *Memscan) Close() (err error) {
+	if m.freezer != nil {
+		m.freezer.close()
+		m.freezer = nil
+	}
+	if m.patcher != nil {
+		m.patcher.close()
+		m.patcher = nil
+	}
 	if m.maps != nil {
 		err = m.maps.Close()
 		m.maps = nil
@@ -112,19 +208,47 @@ func (m *Memscan) Close() (err error) {
 	return
 }
 
+// Results 未知初始值扫描后 (IsSnapshot) 结果没有逐个保存, 返回 nil
 func (m *Memscan) Results() []uint64 {
-	if m.results == nil {
+	if m.results == nil || m.snapshot != nil {
 		return nil
 	}
 	return m.results.Data()
 }
 
+// SetResultsLimit 每一轮最多保存 n 个结果, n <= 0 时不限制
+// 超出的结果被丢弃, ScanReport.Truncated 为 true, Warnings 中包含上限
+func (m *Memscan) SetResultsLimit(n int) {
+	m.resultsLimit = max(n, 0)
+	for _, buf := range []*MmapUint64{m.results, m.values, m.types} {
+		if buf != nil {
+			buf.SetLimit(m.resultsLimit)
+		}
+	}
+}
+
+func (m *Memscan) ResultsLimit() int {
+	return m.resultsLimit
+}
+
 func (m *Memscan) Count() int {
+	if m.snapshot != nil {
+		return m.snapshot.Count()
+	}
 	if m.results == nil {
 		return 0
 	}
 	return m.results.Len()
 }
+
+// IsSnapshot 当前结果是否为未知初始值扫描的快照
+func (m *Memscan) IsSnapshot() bool {
+	return m.snapshot != nil
+}
+
+func (m *Memscan) ValueType() scanner.Type {
+	return m.valueType
+}
 func (m *Memscan) Rounds() uint {
 	return m.round
 }
@@ -139,18 +263,28 @@ func (m *Memscan) String() string {
 func (m *Memscan) Reset() {
 	m.Cancel()
 	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.clearHistory()
 	if m.results != nil {
 		m.results.Clear()
 	}
-	if m.prevResults != nil {
-		m.prevResults.Clear()
+	if m.values != nil {
+		m.values.Clear()
+	}
+	if m.types != nil {
+		m.types.Clear()
 	}
+	if m.snapshot != nil {
+		m.snapshot.Destroy()
+		m.snapshot = nil
+	}
+	m.step = HistoryEntry{}
 	m.round = 0
-	m.canUndo = false
+	m.dirtyValid = false
+	m.progress.Store(nil)
 }
 
 func (m *Memscan) SearchInResults(address uint64) int {
-	if m.results == nil || m.results.Len() == 0 {
+	if m.results == nil || m.results.Len() == 0 || m.snapshot != nil {
 		return -1
 	}
 	i, found := slices.BinarySearch(m.results.Data(), address)
@@ -160,8 +294,12 @@ func (m *Memscan) SearchInResults(address uint64) int {
 	return -1
 }
 
+// RenderResults IsMulti 时每个结果按自己的类型显示, 忽略 valueType
 func (m *Memscan) RenderResults(valueType *scanner.Value) (rows [][2]string) {
-	if valueType == nil {
+	if m.IsMulti() {
+		return m.renderMultiResults()
+	}
+	if valueType == nil || m.snapshot != nil {
 		return
 	}
 	count := m.Count()
@@ -175,8 +313,7 @@ func (m *Memscan) RenderResults(valueType *scanner.Value) (rows [][2]string) {
 	defer freeReadBuffer(buf)
 
 	m.readValuesRaw(m.results.Data(), valueSize, valueType.DisturbingByte(), buf)
-	value := &scanner.Value{}
-	value.SetType(valueType.Type())
+	value := displayValue(valueType)
 
 	rows = make([][2]string, 0, count)
 	for i := 0; i < count; i++ {
@@ -194,20 +331,58 @@ func (m *Memscan) RenderResults(valueType *scanner.Value) (rows [][2]string) {
 	return
 }
 
+// displayValue 用于显示内存中读取的值
+// Bytes, String 的长度取决于扫描值, 显示内存中实际的内容
+func displayValue(valueType *scanner.Value) *scanner.Value {
+	switch valueType.Type() {
+	case scanner.Bytes:
+		return scanner.NewBytes(make([]byte, valueType.Size()))
+	case scanner.String:
+		return scanner.NewText(make([]byte, valueType.Size()), valueType.Encoding())
+	}
+	value := &scanner.Value{}
+	value.SetType(valueType.Type())
+	return value
+}
+
 func (m *Memscan) ChangeResultsValues(retIndexes []int, value *scanner.Value) {
-	n := m.Count()
-	if n == 0 {
+	// 特征码包含通配符, 无法写入
+	if value.IsPattern() {
 		return
 	}
+	address := m.resultAddresses(retIndexes, value.Type())
+	if len(address) > 0 {
+		_, _ = m.writeValues(address, value)
+	}
+}
+
+// resultAddresses retIndexes 为空时返回所有结果
+// IsMulti 时仅返回类型为 typ 的结果
+func (m *Memscan) resultAddresses(retIndexes []int, typ scanner.Type) (address []uint64) {
+	n := m.Count()
+	if n == 0 || m.snapshot != nil {
+		return nil
+	}
+	types := m.ResultTypes()
+	matchType := func(idx int) bool {
+		return types == nil || types[idx] == typ
+	}
+
 	c := len(retIndexes)
-	var address []uint64
 	// change all values
-	if c == 0 {
+	if c == 0 && types == nil {
 		address = m.results.Data()
+	} else if c == 0 {
+		address = make([]uint64, 0, n)
+		for idx, addr := range m.results.Data() {
+			if matchType(idx) {
+				address = append(address, addr)
+			}
+		}
 	} else {
 		address = make([]uint64, 0, c)
 		for _, idx := range retIndexes {
-			if idx > -1 && idx < n {
+			if idx > -1 && idx < n && matchType(idx) {
 				addr, ok := m.results.Index(idx)
 				if ok {
 					address = append(address, addr)
@@ -215,9 +390,7 @@ func (m *Memscan) ChangeResultsValues(retIndexes []int, value *scanner.Value) {
 			}
 		}
 	}
-	if len(address) > 0 {
-		_, _ = m.writeValues(address, value)
-	}
+	return
 }
 
 func (m *Memscan) ChangeValues(address []uint64, value *scanner.Value) {
@@ -231,29 +404,47 @@ func (m *Memscan) ChangeValues(address []uint64, value *scanner.Value) {
 }
 
 // 确保 len(addresses) <= IOV_MAX
-func (m *Memscan) filterResults(addresses []uint64, valueSize int, comp scanner.ValueComparable, disturb byte, readBuffer []byte, results *MmapUint64) {
-	m.readValuesRaw(addresses, valueSize, disturb, readBuffer)
+// 读取失败的地址直接丢弃, 比较扫描 (例如 NotEqual) 时 disturb 字节仍可能满足条件
+// 返回值与 readValuesRawFunc 相同
+func (m *Memscan) filterResults(addresses []uint64, valueSize int, comp scanner.ValueComparable, readBuffer []byte, results *MmapUint64) (read int, err error) {
+	var failed [IOV_MAX]bool
+	read, err = m.readValuesRawFunc(addresses, valueSize, readBuffer, func(i int) {
+		failed[i] = true
+	})
+	p := m.progress.Load()
 
 	var batch [collectBatchSize]uint64
 	var count int
 
 	for i := range addresses {
+		if failed[i] {
+			continue
+		}
 		offset := i * valueSize
 		if comp.EqualBytes(readBuffer[offset : offset+valueSize]) {
 			batch[count] = addresses[i]
 			count++
 			if count == collectBatchSize {
-				_ = results.Put(batch[:]...)
+				p.put(results, batch[:]...)
 				count = 0
 			}
 		}
 	}
 	if count > 0 {
-		_ = results.Put(batch[:count]...)
+		p.put(results, batch[:count]...)
 	}
+	return
 }
 
 func (m *Memscan) readValuesRaw(addresses []uint64, size int, disturb byte, buf []byte) {
+	m.readValuesRawFunc(addresses, size, buf, func(i int) {
+		buf[i*size] = disturb
+	})
+}
+
+// readValuesRawFunc 读取失败的地址索引会传给 onFail
+// 返回成功读取的字节数和第一个读取错误, 部分读取时内核不返回原因, 记为 unix.EFAULT
+func (m *Memscan) readValuesRawFunc(addresses []uint64, size int, buf []byte, onFail func(i int)) (read int, readErr error) {
 	if size <= 0 {
 		return
 	}
@@ -281,17 +472,28 @@ func (m *Memscan) readValuesRaw(addresses []uint64, size int, disturb byte, buf
 			remote[i].Len = size
 		}
 
-		nRead, err := unix.ProcessVMReadv(m.proc.PID, local[:remaining], remote[:remaining], 0)
+		nRead, err := m.mem.ReadV(local[:remaining], remote[:remaining])
+		nRead = max(nRead, 0)
 		successCount := nRead / size
 		currentPos += successCount
+		read += successCount * size
 
 		if currentPos < n {
-			offset := currentPos * size
-			buf[offset] = disturb
+			onFail(currentPos)
 			currentPos++
+			if readErr == nil {
+				readErr = err
+				if readErr == nil {
+					readErr = unix.EFAULT
+				}
+			}
 		}
 
 		if err != nil && !errors.Is(err, unix.EFAULT) && nRead == 0 {
+			// 进程不存在等, 剩余的地址都无法读取
+			for ; currentPos < n; currentPos++ {
+				onFail(currentPos)
+			}
 			break
 		}
 	}
@@ -315,6 +517,8 @@ func (m *Memscan) writeValues(addresses []uint64, value *scanner.Value) (int, er
 	totalWritten := 0
 	currentPos := 0
 	var lastErr error
+	// readOnly 第一次需要强制写入时读取
+	var readOnly Regions
 
 	localPtr := getLocalIovec()
 	remotePtr := getRemoteIovec()
@@ -338,7 +542,7 @@ func (m *Memscan) writeValues(addresses []uint64, value *scanner.Value) (int, er
 			}
 		}
 
-		nWrite, err := unix.ProcessVMWritev(m.proc.PID, local[:remaining], remote[:remaining], 0)
+		nWrite, err := m.mem.WriteV(local[:remaining], remote[:remaining])
 
 		totalWritten += nWrite
 		successCount := nWrite / size
@@ -346,11 +550,25 @@ func (m *Memscan) writeValues(addresses []uint64, value *scanner.Value) (int, er
 
 		// 如果没写完，说明 currentPos 对应的地址无效
 		if currentPos < n {
-			lastErr = err // 通常是 EFAULT
 			// 只有在遇到致命错误（如进程不存在）且一个都没写成功时才彻底退出
 			if err != nil && !errors.Is(err, unix.EFAULT) && nWrite == 0 {
 				return totalWritten, err
 			}
+			totalWritten -= nWrite % size
+			// 只读页面 (例如代码段), 开启 SetForceWrites 时通过 /proc/pid/mem 写入并备份原始数据
+			// 未映射或已释放的地址直接跳过
+			if m.forceWrites && m.maps != nil {
+				if readOnly == nil {
+					readOnly = m.maps.all()
+				}
+				if inReadOnlyRegion(readOnly, addresses[currentPos], size) {
+					if _, perr := m.patcher.write(addresses[currentPos], rawData); perr == nil {
+						totalWritten += size
+					} else {
+						lastErr = perr
+					}
+				}
+			}
 
 			// skip invalid address
 			currentPos++
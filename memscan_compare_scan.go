@@ -0,0 +1,554 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+// FirstScanUnknown 未知初始值扫描
+// 保存所有可写 Region 的快照, 之后使用 NextScanCompare 与上一轮比较, 或 NextScan 搜索精确值
+func (m *Memscan) FirstScanUnknown(typ scanner.Type, args ...bool) *ScanReport {
+	m.Reset()
+
+	if m.results == nil {
+		return errReport(ErrScanBuffer)
+	}
+	if !typ.Numeric() {
+		return errReport(ErrScanValue)
+	}
+
+	if !m.alive() {
+		_ = m.Close()
+		return errReport(ErrNoProcess)
+	}
+
+	var processPaused bool
+	if len(args) > 0 {
+		processPaused = args[0]
+	}
+
+	if !processPaused && m.proc != nil {
+		m.proc.Pause()
+		defer m.proc.Resume()
+	}
+
+	st := time.Now()
+	m.restartDirty()
+	regions := m.maps.Parse(REGION_ALL_RW)
+	regions = RegionsOptimize(regions)
+
+	var total uint64
+	for _, region := range regions {
+		total += region.Size
+	}
+	m.beginProgress(len(regions), total)
+	snap := m.captureSnapshot(regions, typ.ByteSize())
+	m.endProgress()
+	m.round += 1
+	m.valueType = typ
+	m.snapshot = snap
+	m.step = HistoryEntry{Round: m.round, Scan: fmt.Sprintf("%s unknown initial value", typ)}
+
+	return m.report(st)
+}
+
+// captureSnapshot 并发读取 regions, 在 beginProgress 之后调用
+func (m *Memscan) captureSnapshot(regions []Region, valueSize int) *Snapshot {
+	snap := &Snapshot{
+		regions:   make([]*SnapshotRegion, 0, len(regions)),
+		valueSize: valueSize,
+	}
+	p := m.progress.Load()
+
+	var wg sync.WaitGroup
+	for _, region := range regions {
+		if err := m.sem.Acquire(m.ctx, 1); err != nil {
+			break // Canceled
+		}
+		sr, err := newSnapshotRegion(region)
+		if err != nil {
+			p.warn("snapshot buffer: %v", err)
+			m.sem.Release(1)
+			continue
+		}
+		snap.regions = append(snap.regions, sr)
+		wg.Add(1)
+		go m.taskSnapshot(sr, &wg)
+	}
+	wg.Wait()
+	return snap
+}
+
+func (m *Memscan) taskSnapshot(sr *SnapshotRegion, wg *sync.WaitGroup) {
+	defer m.sem.Release(1)
+	defer wg.Done()
+
+	p := m.progress.Load()
+	buf := getScanBuffer()
+	read, err := sr.capture(m.mem, buf)
+	freeScanBuffer(buf)
+	p.record(sr.Start, sr.End, read, err)
+	p.add(sr.Size, 0)
+}
+
+// NextScanCompare 与上一轮的值比较 (changed, unchanged, increased, decreased, increased by N, decreased by N)
+// cmp.Type() 必须与首次扫描的类型一致
+func (m *Memscan) NextScanCompare(cmp *scanner.Comparison) *ScanReport {
+	if err := m.canCompare(cmp); err != nil {
+		return errReport(err)
+	}
+	if m.snapshot != nil {
+		return m.nextScanCompareSnapshot(cmp)
+	}
+	if m.results.Len() > nextScanSparseThreshold {
+		return m.nextScanCompareDense(cmp)
+	}
+	return m.nextScanCompareSparse(cmp)
+}
+
+// NextScanCompareContext 与 NextScanContext 相同
+func (m *Memscan) NextScanCompareContext(ctx context.Context, cmp *scanner.Comparison) *ScanReport {
+	defer m.bindContext(ctx)()
+	return m.NextScanCompare(cmp)
+}
+
+func (m *Memscan) NextScanCompareForceDense(cmp *scanner.Comparison) *ScanReport {
+	if err := m.canCompare(cmp); err != nil {
+		return errReport(err)
+	}
+	if m.snapshot != nil {
+		return m.nextScanCompareSnapshot(cmp)
+	}
+	return m.nextScanCompareDense(cmp)
+}
+
+func (m *Memscan) NextScanCompareForceSparse(cmp *scanner.Comparison) *ScanReport {
+	if err := m.canCompare(cmp); err != nil {
+		return errReport(err)
+	}
+	if m.snapshot != nil {
+		return m.nextScanCompareSnapshot(cmp)
+	}
+	return m.nextScanCompareSparse(cmp)
+}
+
+func (m *Memscan) canCompare(cmp *scanner.Comparison) error {
+	if m.results == nil {
+		return ErrScanBuffer
+	}
+	if cmp == nil {
+		return ErrScanValue
+	}
+	if m.round == 0 {
+		return ErrScanRound
+	}
+	if cmp.Type() != m.valueType {
+		return ErrScanType
+	}
+	if m.snapshot != nil {
+		return nil
+	}
+	count := m.results.Len()
+	if count == 0 || m.values.Len() != count {
+		return ErrScanRound
+	}
+	return nil
+}
+
+func (m *Memscan) nextScanCompareSnapshot(cmp *scanner.Comparison) *ScanReport {
+	st := time.Now()
+	regions := m.snapshot.regions
+	m.regionBuffers = make([]*MmapUint64, len(regions))
+	m.regionValues = make([]*MmapUint64, len(regions))
+	var total uint64
+	for _, sr := range regions {
+		total += sr.Size
+	}
+	m.beginProgress(len(regions), total)
+	dirty := m.takeDirty(len(regions), func(i int) (uint64, uint64) {
+		return regions[i].Start, regions[i].End
+	})
+
+	var wg sync.WaitGroup
+	for index, sr := range regions {
+		if err := m.sem.Acquire(m.ctx, 1); err != nil {
+			break // Canceled
+		}
+		wg.Add(1)
+		go m.taskCompareSnapshot(cmp, index, sr, dirty[index], &wg)
+	}
+
+	wg.Wait()
+	m.endProgress()
+	m.commitResults(compareStep(cmp))
+
+	return m.report(st)
+}
+
+func (m *Memscan) taskCompareSnapshot(cmp *scanner.Comparison, regionIndex int, sr *SnapshotRegion, dirty *dirtyPages, wg *sync.WaitGroup) {
+	defer m.sem.Release(1)
+	defer wg.Done()
+
+	p := m.progress.Load()
+	size := cmp.Size()
+	collector, err := m.newPairCollector(int(sr.Size) / size)
+	if err != nil {
+		p.warn("region buffer: %v", err)
+		return
+	}
+	collector.progress = p
+
+	// 大部分页面不会变化, 整页相同时结果只取决于 cmp 对相同值的判断
+	sameMatched := cmp.Match(0, 0)
+
+	compare := func(addr uint64, data []byte) bool {
+		prev := sr.data[addr-sr.Start:]
+		// readRegion 的每一段都从页面边界开始
+		for page := 0; page < len(data); page += memPageSize {
+			if !sr.Valid(addr + uint64(page)) {
+				continue
+			}
+			end := min(page+memPageSize, len(data))
+			if bytes.Equal(prev[page:end], data[page:end]) {
+				if sameMatched {
+					for i := page; i+size <= end; i += size {
+						collector.put(addr+uint64(i), scanner.RawBits(data[i:i+size]))
+					}
+				}
+				continue
+			}
+			for i := page; i+size <= end; i += size {
+				curr := scanner.RawBits(data[i : i+size])
+				if cmp.Match(scanner.RawBits(prev[i:i+size]), curr) {
+					collector.put(addr+uint64(i), curr)
+				}
+			}
+		}
+		return m.ctx.Err() == nil
+	}
+
+	buf := getScanBuffer()
+	var read uint64
+	if dirty == nil {
+		read, err = readRegion(m.mem, sr.Start, sr.End, buf, compare)
+	} else {
+		// 没有写入过的页面与快照相同, 不需要读取
+		for start := sr.Start; start < sr.End && m.ctx.Err() == nil; {
+			end, written := dirty.run(start, sr.End)
+			if written {
+				n, e := readRegion(m.mem, start, end, buf, compare)
+				read += n
+				if err == nil {
+					err = e
+				}
+			} else if sameMatched {
+				compare(start, sr.data[start-sr.Start:end-sr.Start])
+			}
+			start = end
+		}
+	}
+	freeScanBuffer(buf)
+
+	collector.flush()
+	p.record(sr.Start, sr.End, read, err)
+	p.add(sr.Size, collector.addresses.Len())
+	m.regionBuffers[regionIndex], m.regionValues[regionIndex] = collector.release()
+}
+
+func (m *Memscan) nextScanCompareDense(cmp *scanner.Comparison) *ScanReport {
+	st := time.Now()
+	regions := BuildVirtualRegions(m.results.Data(), uint64(cmp.Size()))
+	prev := m.values.Data()
+	m.regionBuffers = make([]*MmapUint64, len(regions))
+	m.regionValues = make([]*MmapUint64, len(regions))
+	m.beginProgress(len(regions), virtualRegionsSize(regions))
+	dirty := m.takeDirty(len(regions), func(i int) (uint64, uint64) {
+		return regions[i].Start, regions[i].End
+	})
+
+	var wg sync.WaitGroup
+	for index, region := range regions {
+		if err := m.sem.Acquire(m.ctx, 1); err != nil {
+			break // Canceled
+		}
+		wg.Add(1)
+		go m.taskCompareDense(cmp, index, region, prev[region.index:region.index+region.count], dirty[index], &wg)
+	}
+
+	wg.Wait()
+	m.endProgress()
+	m.commitResults(compareStep(cmp))
+
+	return m.report(st)
+}
+
+func (m *Memscan) taskCompareDense(cmp *scanner.Comparison, regionIndex int, region *VirtualRegion, prev []uint64, dirty *dirtyPages, wg *sync.WaitGroup) {
+	defer m.sem.Release(1)
+	defer wg.Done()
+
+	p := m.progress.Load()
+	collector, err := m.newPairCollector(region.count)
+	if err != nil {
+		p.warn("region buffer: %v", err)
+		return
+	}
+	collector.progress = p
+
+	size := cmp.Size()
+	buf := getScanBuffer()
+	read, err := eachDirtySpan(region, uint64(size), dirty, func(k int, address uint64) {
+		if cmp.Match(prev[k], prev[k]) {
+			collector.put(address, prev[k])
+		}
+	}, func(k int, sub *VirtualRegion) (uint64, error) {
+		return eachVirtualRegionValue(m.mem, sub, size, buf, func(i int, address, curr uint64) {
+			if cmp.Match(prev[k+i], curr) {
+				collector.put(address, curr)
+			}
+		})
+	})
+	freeScanBuffer(buf)
+
+	collector.flush()
+	p.record(region.Start, region.End, read, err)
+	p.add(region.Size, collector.addresses.Len())
+	m.regionBuffers[regionIndex], m.regionValues[regionIndex] = collector.release()
+}
+
+func (m *Memscan) nextScanCompareSparse(cmp *scanner.Comparison) *ScanReport {
+	count := m.results.Len()
+	st := time.Now()
+
+	var wg sync.WaitGroup
+	taskSize := nextScanTaskSize
+	regionSize := count / taskSize
+	if count%taskSize != 0 {
+		regionSize += 1
+	}
+	m.regionBuffers = make([]*MmapUint64, regionSize)
+	m.regionValues = make([]*MmapUint64, regionSize)
+	m.beginProgress(regionSize, uint64(count*cmp.Size()))
+
+	var index int
+	for start := 0; start < count; start += taskSize {
+		if err := m.sem.Acquire(m.ctx, 1); err != nil {
+			break
+		}
+		addresses := m.results.GetN(start, taskSize)
+		prev := m.values.GetN(start, taskSize)
+		if len(addresses) == 0 || len(prev) != len(addresses) {
+			m.sem.Release(1)
+			break
+		}
+
+		wg.Add(1)
+		go m.taskCompareSparse(cmp, index, addresses, prev, &wg)
+		index++
+	}
+
+	wg.Wait()
+	m.endProgress()
+	m.commitResults(compareStep(cmp))
+
+	return m.report(st)
+}
+
+func (m *Memscan) taskCompareSparse(cmp *scanner.Comparison, index int, addresses, prev []uint64, wg *sync.WaitGroup) {
+	defer m.sem.Release(1)
+	defer wg.Done()
+
+	p := m.progress.Load()
+	collector, err := m.newPairCollector(len(addresses))
+	if err != nil {
+		p.warn("region buffer: %v", err)
+		return
+	}
+	collector.progress = p
+
+	n := len(addresses)
+	size := cmp.Size()
+	readBuffer := getReadBuffer(IOV_MAX * size)
+	var failed [IOV_MAX]bool
+	var read int
+	var readErr error
+
+	for start := 0; start < n; start += IOV_MAX {
+		end := min(start+IOV_MAX, n)
+		clear(failed[:])
+		nRead, err := m.readValuesRawFunc(addresses[start:end], size, readBuffer, func(i int) {
+			failed[i] = true
+		})
+		read += nRead
+		if readErr == nil {
+			readErr = err
+		}
+		for i := range end - start {
+			if failed[i] {
+				continue
+			}
+			offset := i * size
+			curr := scanner.RawBits(readBuffer[offset : offset+size])
+			if cmp.Match(prev[start+i], curr) {
+				collector.put(addresses[start+i], curr)
+			}
+		}
+	}
+
+	freeReadBuffer(readBuffer)
+
+	collector.flush()
+	p.record(addresses[0], addresses[n-1]+uint64(size), uint64(read), readErr)
+	p.add(uint64(n*size), collector.addresses.Len())
+	m.regionBuffers[index], m.regionValues[index] = collector.release()
+}
+
+// captureValues 保存本轮结果的当前值, 供下一轮 NextScanCompare 使用
+// 精确值扫描时所有结果的值都等于 value, 无需读取
+// 浮点近似值和比较扫描 (Greater, Between 等) 需要读取实际值
+func (m *Memscan) captureValues(value *scanner.Value) {
+	if m.values == nil {
+		return
+	}
+	m.values.Clear()
+	if !value.Type().Numeric() {
+		return
+	}
+	count := m.results.Len()
+	if count == 0 {
+		return
+	}
+	values, err := m.values.Alloc(count)
+	if err != nil {
+		m.progress.Load().warn("values not saved: %v", err)
+		m.values.Clear()
+		return
+	}
+
+	if !value.HasOption() && !value.HasCompare() {
+		raw := scanner.RawBits(value.Bytes())
+		for i := range values {
+			values[i] = raw
+		}
+		return
+	}
+
+	size := value.Size()
+	regions := BuildVirtualRegions(m.results.Data(), uint64(size))
+
+	var wg sync.WaitGroup
+	for _, region := range regions {
+		if err = m.sem.Acquire(m.ctx, 1); err != nil {
+			break // Canceled
+		}
+		wg.Add(1)
+		go func(region *VirtualRegion) {
+			defer m.sem.Release(1)
+			defer wg.Done()
+
+			buf := getScanBuffer()
+			_, _ = eachVirtualRegionValue(m.mem, region, size, buf, func(k int, _, curr uint64) {
+				values[region.index+k] = curr
+			})
+			freeScanBuffer(buf)
+		}(region)
+	}
+	wg.Wait()
+	// 部分值没有读取, 不能用于下一轮比较
+	if m.ctx.Err() != nil {
+		m.values.Clear()
+	}
+}
+
+// eachVirtualRegionValue 按块读取 VirtualRegion, 依次回调其中每个地址的当前值
+// k 是地址在 region 中的索引, 不可读的地址会被跳过
+// 返回值与 readRegion 相同
+func eachVirtualRegionValue(mem MemoryAccessor, region *VirtualRegion, size int, buf []byte, fn func(k int, address, value uint64)) (uint64, error) {
+	k := 0
+	return readRegion(mem, region.Start, region.End, buf, func(addr uint64, data []byte) bool {
+		end := addr + uint64(len(data))
+		for k < region.count && region.addresses[k] < addr {
+			k++
+		}
+		for k < region.count && region.addresses[k]+uint64(size) <= end {
+			address := region.addresses[k]
+			offset := address - addr
+			fn(k, address, scanner.RawBits(data[offset:offset+uint64(size)]))
+			k++
+		}
+		return k < region.count
+	})
+}
+
+// pairCollector 成对收集地址和值
+type pairCollector struct {
+	addresses *MmapUint64
+	values    *MmapUint64
+	batchAddr [collectBatchSize]uint64
+	batchVal  [collectBatchSize]uint64
+	count     int
+	// progress 记录截断, 可以为 nil
+	progress *scanProgress
+}
+
+func (m *Memscan) newPairCollector(caps int) (*pairCollector, error) {
+	if caps < 1 {
+		caps = 1
+	}
+	addresses, err := m.newBuffer(caps)
+	if err != nil {
+		return nil, err
+	}
+	values, err := m.newBuffer(caps)
+	if err != nil {
+		addresses.Destroy()
+		return nil, err
+	}
+	return &pairCollector{addresses: addresses, values: values}, nil
+}
+
+func (c *pairCollector) put(address, value uint64) {
+	c.batchAddr[c.count] = address
+	c.batchVal[c.count] = value
+	c.count++
+	if c.count == collectBatchSize {
+		c.flush()
+	}
+}
+
+func (c *pairCollector) flush() {
+	if c.count > 0 {
+		c.progress.put(c.addresses, c.batchAddr[:c.count]...)
+		c.progress.put(c.values, c.batchVal[:c.count]...)
+		c.count = 0
+	}
+}
+
+// release 没有结果时销毁并返回 nil
+func (c *pairCollector) release() (addresses, values *MmapUint64) {
+	if c.addresses.Len() == 0 {
+		c.addresses.Destroy()
+		c.values.Destroy()
+		return nil, nil
+	}
+	return c.addresses, c.values
+}
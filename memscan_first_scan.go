@@ -16,22 +16,42 @@
 package memscan
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/kayon/memscan/scanner"
 )
 
-func (m *Memscan) FirstScan(value *scanner.Value, args ...bool) time.Duration {
+func (m *Memscan) FirstScan(value *scanner.Value, args ...bool) *ScanReport {
+	return m.FirstScanContext(context.Background(), value, args...)
+}
+
+// FirstScanContext ctx 取消或超时后停止扫描, 已完成的 Region 作为本轮结果, ScanReport.Canceled 为 true
+func (m *Memscan) FirstScanContext(ctx context.Context, value *scanner.Value, args ...bool) *ScanReport {
+	if value == nil {
+		return errReport(ErrScanValue)
+	}
+	return m.firstScan(ctx, valueStep(value), value, func() *scanner.Scanner {
+		return scanner.NewScanner(m.ctx, *value)
+	}, args...)
+}
+
+// firstScan value 决定结果的类型, 用于 captureValues 和比较扫描
+// value 为 nil 时 (搜索所有数值类型) 记录每个结果的类型
+// newScanner 在 Reset 之后调用, 因为 Reset 会重新创建 m.ctx
+// step 记录在历史中
+func (m *Memscan) firstScan(ctx context.Context, step HistoryEntry, value *scanner.Value, newScanner func() *scanner.Scanner, args ...bool) *ScanReport {
 	m.Reset()
+	defer m.bindContext(ctx)()
 
 	if m.results == nil {
-		return 0
+		return errReport(ErrScanBuffer)
 	}
 
-	if m.proc == nil || !m.proc.Alive() {
+	if !m.alive() {
 		_ = m.Close()
-		return 0
+		return errReport(ErrNoProcess)
 	}
 
 	var processPaused bool
@@ -39,75 +59,137 @@ func (m *Memscan) FirstScan(value *scanner.Value, args ...bool) time.Duration {
 		processPaused = args[0]
 	}
 
-	if !processPaused {
+	if !processPaused && m.proc != nil {
 		m.proc.Pause()
 		defer m.proc.Resume()
 	}
 
 	st := time.Now()
+	m.restartDirty()
 	regions := m.maps.Parse(REGION_ALL_RW)
 	regions = RegionsOptimize(regions)
-	m.regionBuffers = make([]*MmapUint64, len(regions))
 
-	var wg sync.WaitGroup
-	scan := scanner.NewScanner(m.ctx, *value)
-
-	for index, region := range regions {
-		if err := m.sem.Acquire(m.ctx, 1); err != nil {
-			break // Canceled
-		}
-		wg.Add(1)
-		go m.taskFirstScan(scan, index, region, &wg)
+	withTypes := value == nil
+	m.valueType = scanner.Bytes
+	if value != nil {
+		m.valueType = value.Type()
 	}
-
-	wg.Wait()
+	m.scanRegions(newScanner(), regions, withTypes)
 	m.round += 1
+	step.Round = m.round
+	m.step = step
 
 	// 现在, 结果保存地址是有序的, 虽然增加了一点点内存
 	// 但这很值得, 没有排序开销
-	for _, buf := range m.regionBuffers {
+	p := m.progress.Load()
+	for i, buf := range m.regionBuffers {
 		if buf == nil {
 			continue
 		}
-		_ = m.results.Merge(buf)
+		p.truncate(m.results.Merge(buf))
 		buf.Destroy()
+		if withTypes {
+			p.truncate(m.types.Merge(m.regionTypes[i]))
+			m.regionTypes[i].Destroy()
+		}
 	}
 	m.regionBuffers = nil
+	m.regionTypes = nil
+	if value != nil {
+		m.captureValues(value)
+	}
+
+	return m.report(st)
+}
+
+// scanRegions 在 regions 中搜索, 结果保存在 m.regionBuffers
+// withTypes 时每个结果的类型保存在 m.regionTypes
+func (m *Memscan) scanRegions(scan *scanner.Scanner, regions []Region, withTypes bool) {
+	m.regionBuffers = make([]*MmapUint64, len(regions))
+	if withTypes {
+		m.regionTypes = make([]*MmapUint64, len(regions))
+	}
+
+	var total uint64
+	for _, region := range regions {
+		total += region.Size
+	}
+	m.beginProgress(len(regions), total)
+
+	var wg sync.WaitGroup
 
-	return time.Since(st)
+	for index, region := range regions {
+		if err := m.sem.Acquire(m.ctx, 1); err != nil {
+			break // Canceled
+		}
+		wg.Add(1)
+		go m.taskFirstScan(scan, index, region, &wg)
+	}
+
+	wg.Wait()
+	m.endProgress()
 }
 
 func (m *Memscan) taskFirstScan(scan *scanner.Scanner, regionIndex int, region Region, wg *sync.WaitGroup) {
 	defer m.sem.Release(1)
 	defer wg.Done()
 
-	buf, err := NewMmapUint64(regionResultsAllocCaps)
+	p := m.progress.Load()
+	buf, err := m.newBuffer(regionResultsAllocCaps)
 	if err != nil {
+		p.warn("region buffer: %v", err)
 		return
 	}
 
-	var batch [collectBatchSize]uint64
+	var typeBuf *MmapUint64
+	if m.regionTypes != nil {
+		if typeBuf, err = m.newBuffer(regionResultsAllocCaps); err != nil {
+			p.warn("region buffer: %v", err)
+			buf.Destroy()
+			return
+		}
+	}
+
+	var batch, typeBatch [collectBatchSize]uint64
 	var count int
 
-	collector := scanner.CollectorFunc(func(offset int) bool {
+	collector := scanner.TypedCollectorFunc(func(offset int, typ scanner.Type) bool {
 		batch[count] = uint64(offset) + region.Start
+		typeBatch[count] = uint64(typ)
 		count++
 		if count == collectBatchSize {
-			_ = buf.Put(batch[:]...)
+			p.put(buf, batch[:]...)
+			if typeBuf != nil {
+				p.put(typeBuf, typeBatch[:]...)
+			}
 			count = 0
 		}
 		return true
 	})
 
-	scan.ScanCollector(region.Pipe(m.proc.PID), collector, nil)
+	r := getRegionReader(m.mem, region.Start, region.End)
+	scan.ScanTypedCollector(keepOpen{r}, collector, nil)
+	read, err := r.Stat()
+	_ = r.Close()
 
 	if count > 0 {
-		_ = buf.Put(batch[:count]...)
+		p.put(buf, batch[:count]...)
+		if typeBuf != nil {
+			p.put(typeBuf, typeBatch[:count]...)
+		}
 	}
+	p.record(region.Start, region.End, read, err)
+	p.add(region.Size, buf.Len())
 
 	if buf.Len() > 0 {
 		m.regionBuffers[regionIndex] = buf
+		if typeBuf != nil {
+			m.regionTypes[regionIndex] = typeBuf
+		}
 	} else {
 		buf.Destroy()
+		if typeBuf != nil {
+			typeBuf.Destroy()
+		}
 	}
 }
@@ -0,0 +1,162 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+// FirstScanGroup 搜索 group, 结果为 group 第一个值的地址
+// 之后可以继续使用 NextScanGroup, 或者以第一个值的类型使用 NextScan, NextScanCompare
+func (m *Memscan) FirstScanGroup(group *scanner.Group, args ...bool) *ScanReport {
+	if group == nil {
+		return errReport(ErrScanValue)
+	}
+	return m.firstScan(context.Background(), groupStep(group), group.Members()[0], func() *scanner.Scanner {
+		return scanner.NewGroupScanner(m.ctx, group)
+	}, args...)
+}
+
+// NextScanGroup 在上一轮结果中过滤 group, 第一个值的类型必须与首次扫描的类型一致
+func (m *Memscan) NextScanGroup(group *scanner.Group) *ScanReport {
+	if m.results == nil {
+		return errReport(ErrScanBuffer)
+	}
+	if group == nil {
+		return errReport(ErrScanValue)
+	}
+	anchor := group.Members()[0]
+	if anchor.Type() != m.valueType {
+		return errReport(ErrScanType)
+	}
+	count := m.results.Len()
+	if count == 0 || m.snapshot != nil {
+		return errReport(ErrScanRound)
+	}
+	st := time.Now()
+
+	var wg sync.WaitGroup
+	taskSize := nextScanTaskSize
+	regionSize := count / taskSize
+	if count%taskSize != 0 {
+		regionSize += 1
+	}
+	m.regionBuffers = make([]*MmapUint64, regionSize)
+	m.beginProgress(regionSize, uint64(count*group.Size()))
+
+	var index int
+	for start := 0; start < count; start += taskSize {
+		if err := m.sem.Acquire(m.ctx, 1); err != nil {
+			break
+		}
+		batchCount := min(taskSize, count-start)
+		addresses := m.results.GetN(start, batchCount)
+		if len(addresses) == 0 {
+			m.sem.Release(1)
+			break
+		}
+
+		wg.Add(1)
+		go m.taskNextScanGroup(index, addresses, group, &wg)
+		index++
+	}
+
+	wg.Wait()
+	m.endProgress()
+	m.commitResults(groupStep(group))
+	m.captureValues(anchor)
+
+	return m.report(st)
+}
+
+func (m *Memscan) taskNextScanGroup(index int, addresses []uint64, group *scanner.Group, wg *sync.WaitGroup) {
+	defer m.sem.Release(1)
+	defer wg.Done()
+
+	p := m.progress.Load()
+	results, err := m.newBuffer(nextScanTaskSize)
+	if err != nil {
+		p.warn("region buffer: %v", err)
+		return
+	}
+	n := len(addresses)
+	window := group.Size()
+	readBuffer := make([]byte, IOV_MAX*window)
+
+	var batch [collectBatchSize]uint64
+	var count, read int
+	var readErr error
+	for start := 0; start < n; start += IOV_MAX {
+		end := min(start+IOV_MAX, n)
+		chunk := addresses[start:end]
+
+		var failed [IOV_MAX]bool
+		nRead, err := m.readValuesRawFunc(chunk, window, readBuffer, func(i int) {
+			failed[i] = true
+		})
+		read += nRead
+		if readErr == nil {
+			readErr = err
+		}
+		for i, address := range chunk {
+			b := readBuffer[i*window : (i+1)*window]
+			if failed[i] {
+				if b = m.readGroupTail(address, b); b == nil {
+					continue
+				}
+			}
+			if group.MatchAt(b, address) {
+				batch[count] = address
+				count++
+				if count == collectBatchSize {
+					p.put(results, batch[:]...)
+					count = 0
+				}
+			}
+		}
+	}
+	if count > 0 {
+		p.put(results, batch[:count]...)
+	}
+	p.record(addresses[0], addresses[n-1]+uint64(window), uint64(read), readErr)
+	p.add(uint64(n*window), results.Len())
+
+	if results.Len() > 0 {
+		m.regionBuffers[index] = results
+	} else {
+		results.Destroy()
+	}
+}
+
+// readGroupTail 结果靠近 Region 末尾时窗口跨出可读范围, 只读取到页末
+func (m *Memscan) readGroupTail(address uint64, b []byte) []byte {
+	tail := int(memPageSize - address%memPageSize)
+	if tail >= len(b) {
+		return nil
+	}
+	ok := true
+	m.readValuesRawFunc([]uint64{address}, tail, b, func(int) {
+		ok = false
+	})
+	if !ok {
+		return nil
+	}
+	return b[:tail]
+}
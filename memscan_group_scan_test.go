@@ -0,0 +1,56 @@
+package memscan
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+func TestMemscan_GroupTail(t *testing.T) {
+	const base = 0x10000
+	data := make([]byte, memPageSize)
+	put := func(off int, hp uint32) {
+		binary.LittleEndian.PutUint32(data[off:], hp)
+		binary.LittleEndian.PutUint32(data[off+4:], 50)
+		binary.LittleEndian.PutUint32(data[off+8:], math.Float32bits(3.5))
+	}
+	put(0x100, 100)
+	// Region 末尾, 窗口跨出可读范围
+	put(memPageSize-12, 100)
+
+	mem := NewFakeMemory("/fake/game")
+	if err := mem.Map(base, data, ParsePermissions("rw-p"), ""); err != nil {
+		t.Fatal(err)
+	}
+	m := NewMemscan()
+	if err := m.OpenAccessor(mem, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	group, err := scanner.ParseGroup("Int32:100; Int32:50; Float32:3.5", 32, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint64{base + 0x100, base + memPageSize - 12}
+	if report := m.FirstScanGroup(group); report.Err != nil {
+		t.Fatal(report.Err)
+	}
+	if got := slices.Sorted(slices.Values(m.Results())); !slices.Equal(got, want) {
+		t.Fatalf("first scan: got %X, want %X", got, want)
+	}
+	if report := m.NextScanGroup(group); report.Err != nil {
+		t.Fatal(report.Err)
+	}
+	if got := slices.Sorted(slices.Values(m.Results())); !slices.Equal(got, want) {
+		t.Fatalf("next scan: got %X, want %X", got, want)
+	}
+
+	put(memPageSize-12, 99)
+	if report := m.NextScanGroup(group); report.Matches != 1 {
+		t.Fatalf("changed: %+v", report)
+	}
+}
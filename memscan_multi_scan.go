@@ -0,0 +1,222 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+// multiReadSize 数值类型最大 8 字节, 且按自身大小对齐
+// 因此读取地址所在的 8 字节对齐块不会跨页
+const multiReadSize = 8
+
+// FirstScanMulti 同时搜索 multi 中所有的数值类型, 并记录每个结果匹配的类型
+// 同一地址可能以多种类型出现在结果中
+func (m *Memscan) FirstScanMulti(multi *scanner.Multi, args ...bool) *ScanReport {
+	if multi == nil || len(multi.Values()) == 0 {
+		return errReport(ErrScanValue)
+	}
+	return m.firstScan(context.Background(), multiStep(multi), nil, func() *scanner.Scanner {
+		return scanner.NewMultiScanner(m.ctx, multi)
+	}, args...)
+}
+
+// NextScanMulti 按每个结果自己的类型与 multi 中对应类型的值比较, multi 中不包含的类型会被过滤掉
+func (m *Memscan) NextScanMulti(multi *scanner.Multi) *ScanReport {
+	if multi == nil {
+		return errReport(ErrScanValue)
+	}
+	if !m.IsMulti() {
+		return errReport(ErrScanRound)
+	}
+	count := m.results.Len()
+	st := time.Now()
+
+	var wg sync.WaitGroup
+	taskSize := nextScanTaskSize
+	regionSize := count / taskSize
+	if count%taskSize != 0 {
+		regionSize += 1
+	}
+	m.regionBuffers = make([]*MmapUint64, regionSize)
+	m.regionTypes = make([]*MmapUint64, regionSize)
+	m.beginProgress(regionSize, uint64(count*multiReadSize))
+
+	var index int
+	for start := 0; start < count; start += taskSize {
+		if err := m.sem.Acquire(m.ctx, 1); err != nil {
+			break
+		}
+		batchCount := min(taskSize, count-start)
+		addresses := m.results.GetN(start, batchCount)
+		types := m.types.GetN(start, batchCount)
+		if len(addresses) == 0 || len(types) != len(addresses) {
+			m.sem.Release(1)
+			break
+		}
+
+		wg.Add(1)
+		go m.taskNextScanMulti(index, addresses, types, multi, &wg)
+		index++
+	}
+
+	wg.Wait()
+	m.endProgress()
+	m.commitResults(multiStep(multi))
+
+	return m.report(st)
+}
+
+func (m *Memscan) taskNextScanMulti(index int, addresses, types []uint64, multi *scanner.Multi, wg *sync.WaitGroup) {
+	defer m.sem.Release(1)
+	defer wg.Done()
+
+	p := m.progress.Load()
+	results, err := m.newBuffer(nextScanTaskSize)
+	if err != nil {
+		p.warn("region buffer: %v", err)
+		return
+	}
+	resultTypes, err := m.newBuffer(nextScanTaskSize)
+	if err != nil {
+		p.warn("region buffer: %v", err)
+		results.Destroy()
+		return
+	}
+
+	n := len(addresses)
+	readBuffer := getReadBuffer(IOV_MAX * multiReadSize)
+	aligned := make([]uint64, IOV_MAX)
+
+	var batch, typeBatch [collectBatchSize]uint64
+	var count, read int
+	var readErr error
+	for start := 0; start < n; start += IOV_MAX {
+		end := min(start+IOV_MAX, n)
+		for i, address := range addresses[start:end] {
+			aligned[i] = address &^ (multiReadSize - 1)
+		}
+
+		var failed [IOV_MAX]bool
+		nRead, err := m.readValuesRawFunc(aligned[:end-start], multiReadSize, readBuffer, func(i int) {
+			failed[i] = true
+		})
+		read += nRead
+		if readErr == nil {
+			readErr = err
+		}
+		for i := start; i < end; i++ {
+			if failed[i-start] {
+				continue
+			}
+			offset := (i-start)*multiReadSize + int(addresses[i]&(multiReadSize-1))
+			if !multi.Match(scanner.Type(types[i]), readBuffer[offset:(i-start+1)*multiReadSize]) {
+				continue
+			}
+			batch[count] = addresses[i]
+			typeBatch[count] = types[i]
+			count++
+			if count == collectBatchSize {
+				p.put(results, batch[:]...)
+				p.put(resultTypes, typeBatch[:]...)
+				count = 0
+			}
+		}
+	}
+	freeReadBuffer(readBuffer)
+
+	if count > 0 {
+		p.put(results, batch[:count]...)
+		p.put(resultTypes, typeBatch[:count]...)
+	}
+	p.record(addresses[0], addresses[n-1]+multiReadSize, uint64(read), readErr)
+	p.add(uint64(n*multiReadSize), results.Len())
+
+	if results.Len() > 0 {
+		m.regionBuffers[index] = results
+		m.regionTypes[index] = resultTypes
+	} else {
+		results.Destroy()
+		resultTypes.Destroy()
+	}
+}
+
+// IsMulti 结果是否来自 FirstScanMulti, 此时每个结果有自己的类型
+func (m *Memscan) IsMulti() bool {
+	if m.results == nil || m.types == nil || m.snapshot != nil {
+		return false
+	}
+	count := m.results.Len()
+	return count > 0 && m.types.Len() == count
+}
+
+// ResultTypes 与 Results 一一对应, 非 IsMulti 时返回 nil
+func (m *Memscan) ResultTypes() []scanner.Type {
+	if !m.IsMulti() {
+		return nil
+	}
+	raw := m.types.Data()
+	types := make([]scanner.Type, len(raw))
+	for i, t := range raw {
+		types[i] = scanner.Type(t)
+	}
+	return types
+}
+
+// renderMultiResults 每个结果按自己的类型显示, 例如 "1500 (Int32)"
+func (m *Memscan) renderMultiResults() (rows [][2]string) {
+	addresses := m.results.Data()
+	types := m.types.Data()
+	count := len(addresses)
+
+	aligned := make([]uint64, count)
+	for i, address := range addresses {
+		aligned[i] = address &^ (multiReadSize - 1)
+	}
+	buf := getReadBuffer(count * multiReadSize)
+	defer freeReadBuffer(buf)
+
+	failed := make([]bool, count)
+	for start := 0; start < count; start += IOV_MAX {
+		end := min(start+IOV_MAX, count)
+		m.readValuesRawFunc(aligned[start:end], multiReadSize, buf[start*multiReadSize:], func(i int) {
+			failed[start+i] = true
+		})
+	}
+
+	rows = make([][2]string, 0, count)
+	value := &scanner.Value{}
+	for i, address := range addresses {
+		typ := scanner.Type(types[i])
+		text := "??"
+		if !failed[i] {
+			offset := i*multiReadSize + int(address&(multiReadSize-1))
+			value.SetType(typ)
+			value.SetBytes(buf[offset : offset+typ.ByteSize()])
+			text = value.Format()
+		}
+		rows = append(rows, [2]string{
+			fmt.Sprintf("%08X", address),
+			fmt.Sprintf("%s (%s)", text, typ),
+		})
+	}
+	return
+}
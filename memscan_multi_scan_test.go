@@ -0,0 +1,52 @@
+package memscan
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+func TestMemscan_NextScanMulti(t *testing.T) {
+	const base = 0x10000
+	data := make([]byte, memPageSize)
+	binary.LittleEndian.PutUint32(data[0x10:], 1234)
+	binary.LittleEndian.PutUint16(data[0x20:], 1234)
+	data[0x22] = 1
+
+	mem := NewFakeMemory("/fake/game")
+	if err := mem.Map(base, data, ParsePermissions("rw-p"), ""); err != nil {
+		t.Fatal(err)
+	}
+	m := NewMemscan()
+	if err := m.OpenAccessor(mem, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	multi, err := scanner.NewMulti("1234", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := m.FirstScanMulti(multi); report.Err != nil || !m.IsMulti() {
+		t.Fatalf("first scan: %+v", report)
+	}
+	count := m.Count()
+
+	// 多类型的结果不能按单一类型继续扫描
+	value := scanner.NewInt32(1234)
+	for name, next := range map[string]func(*scanner.Value) *ScanReport{
+		"NextScan":            m.NextScan,
+		"NextScanForceDense":  m.NextScanForceDense,
+		"NextScanForceSparse": m.NextScanForceSparse,
+	} {
+		if report := next(value); !errors.Is(report.Err, ErrScanRound) || m.Count() != count {
+			t.Errorf("%s: got %v, %d results", name, report.Err, m.Count())
+		}
+	}
+
+	if report := m.NextScanMulti(scanner.NewMultiValues(value)); report.Err != nil || report.Matches != 1 {
+		t.Fatalf("next scan multi: %+v", report)
+	}
+}
@@ -16,6 +16,8 @@
 package memscan
 
 import (
+	"context"
+	"encoding/binary"
 	"sync"
 	"time"
 
@@ -27,35 +29,70 @@ const (
 	nextScanSparseThreshold = IOV_MAX * 32
 )
 
-func (m *Memscan) NextScanForceDense(value *scanner.Value) time.Duration {
-	if m.results == nil || value == nil {
-		return 0
+func (m *Memscan) NextScanForceDense(value *scanner.Value) *ScanReport {
+	if m.results == nil {
+		return errReport(ErrScanBuffer)
+	}
+	if value == nil {
+		return errReport(ErrScanValue)
+	}
+	if m.snapshot != nil {
+		return m.nextScanSnapshot(value)
+	}
+	if m.IsMulti() {
+		return errReport(ErrScanRound)
 	}
 	count := m.results.Len()
 	if count == 0 {
-		return 0
+		return errReport(ErrScanRound)
 	}
 	return m.nextScanDense(value)
 }
 
-func (m *Memscan) NextScanForceSparse(value *scanner.Value) time.Duration {
-	if m.results == nil || value == nil {
-		return 0
+func (m *Memscan) NextScanForceSparse(value *scanner.Value) *ScanReport {
+	if m.results == nil {
+		return errReport(ErrScanBuffer)
+	}
+	if value == nil {
+		return errReport(ErrScanValue)
+	}
+	if m.snapshot != nil {
+		return m.nextScanSnapshot(value)
+	}
+	if m.IsMulti() {
+		return errReport(ErrScanRound)
 	}
 	count := m.results.Len()
 	if count == 0 {
-		return 0
+		return errReport(ErrScanRound)
 	}
 	return m.nextScanSparse(value)
 }
 
-func (m *Memscan) NextScan(value *scanner.Value) time.Duration {
-	if m.results == nil || value == nil {
-		return 0
+// NextScanContext ctx 取消或超时后停止扫描, 已完成的部分作为新一轮结果, ScanReport.Canceled 为 true
+// 上一轮仍在历史中, 可以使用 UndoScan 恢复
+func (m *Memscan) NextScanContext(ctx context.Context, value *scanner.Value) *ScanReport {
+	defer m.bindContext(ctx)()
+	return m.NextScan(value)
+}
+
+// NextScan 结果来自 FirstScanMulti 时返回 ErrScanRound, 需要使用 NextScanMulti
+func (m *Memscan) NextScan(value *scanner.Value) *ScanReport {
+	if m.results == nil {
+		return errReport(ErrScanBuffer)
+	}
+	if value == nil {
+		return errReport(ErrScanValue)
+	}
+	if m.snapshot != nil {
+		return m.nextScanSnapshot(value)
+	}
+	if m.IsMulti() {
+		return errReport(ErrScanRound)
 	}
 	count := m.results.Len()
 	if count == 0 {
-		return 0
+		return errReport(ErrScanRound)
 	}
 
 	if count > nextScanSparseThreshold {
@@ -66,74 +103,98 @@ func (m *Memscan) NextScan(value *scanner.Value) time.Duration {
 
 // nextScanDense 使用了 VirtualRegion 避免了稀疏读取的性能开销
 // 对于千万级结果 nextScanDense 耗时应该也是毫秒级的
-func (m *Memscan) nextScanDense(value *scanner.Value) time.Duration {
+func (m *Memscan) nextScanDense(value *scanner.Value) *ScanReport {
 	st := time.Now()
 	regions := BuildVirtualRegions(m.results.Data(), uint64(value.Size()))
 	m.regionBuffers = make([]*MmapUint64, len(regions))
+	m.beginProgress(len(regions), virtualRegionsSize(regions))
+
+	// 没有写入过的页面中的值与上一轮相同, 需要上一轮的值
+	var prev []uint64
+	dirty := make([]*dirtyPages, len(regions))
+	if value.Type() == m.valueType && value.Type().Numeric() && m.values.Len() == m.results.Len() {
+		prev = m.values.Data()
+		dirty = m.takeDirty(len(regions), func(i int) (uint64, uint64) {
+			return regions[i].Start, regions[i].End
+		})
+	}
 
 	var wg sync.WaitGroup
 	scan := scanner.NewScanner(m.ctx, *value)
+	comp := value.Comparable()
 
 	for index, region := range regions {
 		if err := m.sem.Acquire(m.ctx, 1); err != nil {
 			break // Canceled
 		}
+		var regionPrev []uint64
+		if prev != nil {
+			regionPrev = prev[region.index : region.index+region.count]
+		}
 		wg.Add(1)
-		go m.taskNextScanDense(scan, index, region, int(region.Size)/value.Size(), &wg)
+		go m.taskNextScanDense(scan, comp, index, region, regionPrev, dirty[index], int(region.Size)/value.Size(), &wg)
 	}
 
 	wg.Wait()
-	m.round += 1
-	m.canUndo = true
+	m.endProgress()
+	m.commitResults(valueStep(value))
+	m.captureValues(value)
 
-	m.prevResults.Clear()
-	for _, buf := range m.regionBuffers {
-		if buf == nil {
-			continue
-		}
-		_ = m.prevResults.Merge(buf)
-		buf.Destroy()
-	}
-	m.regionBuffers = nil
-
-	m.results, m.prevResults = m.prevResults, m.results
-
-	return time.Since(st)
+	return m.report(st)
 }
 
-func (m *Memscan) taskNextScanDense(scan *scanner.Scanner, regionIndex int, region *VirtualRegion, bufSize int, wg *sync.WaitGroup) {
+func (m *Memscan) taskNextScanDense(scan *scanner.Scanner, comp scanner.ValueComparable, regionIndex int, region *VirtualRegion, prev []uint64, dirty *dirtyPages, bufSize int, wg *sync.WaitGroup) {
 	defer m.sem.Release(1)
 	defer wg.Done()
 
-	buf, err := NewMmapUint64(bufSize)
+	p := m.progress.Load()
+	buf, err := m.newBuffer(bufSize)
 	if err != nil {
+		p.warn("region buffer: %v", err)
 		return
 	}
 
 	var batch [collectBatchSize]uint64
 	var count int
-
-	collector := func(offset int) bool {
-		address := uint64(offset) + region.Start
-		if region.Match(address) {
-			batch[count] = address
-			count++
-			if count == collectBatchSize {
-				_ = buf.Put(batch[:]...)
-				count = 0
-			}
-			return !region.IsFinished()
+	put := func(address uint64) {
+		batch[count] = address
+		count++
+		if count == collectBatchSize {
+			p.put(buf, batch[:]...)
+			count = 0
 		}
-		return true
 	}
 
-	scan.ScanCollector(region.Pipe(m.proc.PID), collector, &scanner.Options{
-		ExpectedSize: region.Size,
+	size := comp.Size()
+	var raw [8]byte
+	read, err := eachDirtySpan(region, uint64(size), dirty, func(k int, address uint64) {
+		binary.LittleEndian.PutUint64(raw[:], prev[k])
+		if comp.EqualBytes(raw[:size]) {
+			put(address)
+		}
+	}, func(_ int, sub *VirtualRegion) (uint64, error) {
+		collector := func(offset int) bool {
+			address := uint64(offset) + sub.Start
+			if sub.Match(address) {
+				put(address)
+				return !sub.IsFinished()
+			}
+			return true
+		}
+		r := getRegionReader(m.mem, sub.Start, sub.End)
+		scan.ScanCollector(keepOpen{r}, collector, &scanner.Options{
+			ExpectedSize: sub.Size,
+		})
+		read, err := r.Stat()
+		_ = r.Close()
+		return read, err
 	})
 
 	if count > 0 {
-		_ = buf.Put(batch[:count]...)
+		p.put(buf, batch[:count]...)
 	}
+	p.record(region.Start, region.End, read, err)
+	p.add(region.Size, buf.Len())
 
 	if buf.Len() > 0 {
 		m.regionBuffers[regionIndex] = buf
@@ -146,7 +207,7 @@ func (m *Memscan) taskNextScanDense(scan *scanner.Scanner, regionIndex int, regi
 // 主要的性能开销在于非连续地址的稀疏读取, 和 IOV_MAX 以及 bad address 跳过
 // 对于少量结果, 相比 nextScanDense 略微快几十毫秒
 // 虽然微乎其微, 但我还是保留了这个方法
-func (m *Memscan) nextScanSparse(value *scanner.Value) time.Duration {
+func (m *Memscan) nextScanSparse(value *scanner.Value) *ScanReport {
 	count := m.results.Len()
 	st := time.Now()
 
@@ -158,6 +219,7 @@ func (m *Memscan) nextScanSparse(value *scanner.Value) time.Duration {
 		regionSize += 1
 	}
 	m.regionBuffers = make([]*MmapUint64, regionSize)
+	m.beginProgress(regionSize, uint64(count*comp.Size()))
 
 	var index int
 	for start := 0; start < count; start += taskSize {
@@ -170,6 +232,7 @@ func (m *Memscan) nextScanSparse(value *scanner.Value) time.Duration {
 		}
 		addresses := m.results.GetN(start, batchCount)
 		if len(addresses) == 0 {
+			m.sem.Release(1)
 			break
 		}
 
@@ -179,46 +242,87 @@ func (m *Memscan) nextScanSparse(value *scanner.Value) time.Duration {
 	}
 
 	wg.Wait()
-	m.round += 1
-	m.canUndo = true
+	m.endProgress()
+	m.commitResults(valueStep(value))
+	m.captureValues(value)
+
+	return m.report(st)
+}
 
-	m.prevResults.Clear()
-	for _, buf := range m.regionBuffers {
+// nextScanSnapshot 在未知初始值扫描的快照范围内搜索精确值
+func (m *Memscan) nextScanSnapshot(value *scanner.Value) *ScanReport {
+	st := time.Now()
+	regions := make([]Region, len(m.snapshot.regions))
+	for i, sr := range m.snapshot.regions {
+		regions[i] = sr.Region
+	}
+	m.scanRegions(scanner.NewScanner(m.ctx, *value), regions, false)
+	m.commitResults(valueStep(value))
+	m.captureValues(value)
+
+	return m.report(st)
+}
+
+// commitResults 合并 m.regionBuffers (以及 m.regionValues) 作为新一轮的结果
+// 上一轮的结果进入历史, 用于 UndoScan
+func (m *Memscan) commitResults(step HistoryEntry) {
+	m.pushRound()
+	p := m.progress.Load()
+	for i, buf := range m.regionBuffers {
 		if buf == nil {
 			continue
 		}
-		_ = m.prevResults.Merge(buf)
+		p.truncate(m.results.Merge(buf))
 		buf.Destroy()
+		if m.regionValues != nil && m.regionValues[i] != nil {
+			p.truncate(m.values.Merge(m.regionValues[i]))
+			m.regionValues[i].Destroy()
+		}
+		if m.regionTypes != nil && m.regionTypes[i] != nil {
+			p.truncate(m.types.Merge(m.regionTypes[i]))
+			m.regionTypes[i].Destroy()
+		}
 	}
 	m.regionBuffers = nil
+	m.regionValues = nil
+	m.regionTypes = nil
 
-	m.results, m.prevResults = m.prevResults, m.results
-
-	return time.Since(st)
+	m.round += 1
+	step.Round = m.round
+	m.step = step
 }
 
 func (m *Memscan) taskNextScanSparse(index int, addresses []uint64, comp scanner.ValueComparable, wg *sync.WaitGroup) {
 	defer m.sem.Release(1)
 	defer wg.Done()
 
-	results, err := NewMmapUint64(nextScanTaskSize)
+	p := m.progress.Load()
+	results, err := m.newBuffer(nextScanTaskSize)
 	if err != nil {
+		p.warn("region buffer: %v", err)
 		return
 	}
 	n := len(addresses)
 	valueSize := comp.Size()
 	readBuffer := getReadBuffer(IOV_MAX * valueSize)
-	disturb := comp.DisturbingByte()
 
+	var read int
+	var readErr error
 	for start := 0; start < n; start += IOV_MAX {
 		end := start + IOV_MAX
 		if end > n {
 			end = n
 		}
-		m.filterResults(addresses[start:end], valueSize, comp, disturb, readBuffer, results)
+		nRead, err := m.filterResults(addresses[start:end], valueSize, comp, readBuffer, results)
+		read += nRead
+		if readErr == nil {
+			readErr = err
+		}
 	}
 
 	freeReadBuffer(readBuffer)
+	p.record(addresses[0], addresses[n-1]+uint64(valueSize), uint64(read), readErr)
+	p.add(uint64(n*valueSize), results.Len())
 
 	if results.Len() > 0 {
 		m.regionBuffers[index] = results
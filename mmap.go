@@ -25,10 +25,18 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+// ErrMmapCapacity 达到 SetLimit 的上限, 超出的部分被丢弃
+var ErrMmapCapacity = errors.New("mmap capacity exceeded")
+
 type MmapUint64 struct {
 	data   []uint64
 	raw    []byte
 	cursor uint64
+	// limit 最多保存的元素数量, 0 表示不限制
+	limit int
+	// store 不为 nil 时数据映射到 store 文件中 offset 开始的一段
+	store  *resultStore
+	offset int64
 }
 
 func NewMmapUint64(caps int) (*MmapUint64, error) {
@@ -57,47 +65,57 @@ func (m *MmapUint64) Data() []uint64 {
 	return m.data[:m.cursor]
 }
 
+// SetLimit 最多保存 n 个元素, n <= 0 时不限制 (直到 mremap 失败)
+// 已经保存的元素不受影响
+func (m *MmapUint64) SetLimit(n int) {
+	m.limit = max(n, 0)
+}
+
+func (m *MmapUint64) Limit() int {
+	return m.limit
+}
+
 func (m *MmapUint64) Merge(other *MmapUint64) error {
 	if other == nil {
 		return nil
 	}
+	return m.Put(other.Data()...)
+}
 
-	n := uint64(other.Len())
+// Put 容量不足时自动扩容 (会移动 Data), 同一时间只能有一个 goroutine 写入
+// 达到 limit 或扩容失败时写入能容纳的部分并返回错误
+func (m *MmapUint64) Put(items ...uint64) error {
+	n := len(items)
 	if n == 0 {
 		return nil
 	}
-
-	newCount := m.cursor + n
-	if newCount > uint64(len(m.data)) {
-		newCap := len(m.data) * 2
-		if uint64(newCap) < newCount {
-			newCap = int(newCount)
-		}
-		if err := m.grow(newCap); err != nil {
-			return fmt.Errorf("merge grow failed: %w", err)
-		}
+	cursor := m.Len()
+	err := m.reserve(cursor + n)
+	caps := len(m.data)
+	if m.limit > 0 {
+		caps = min(caps, m.limit)
 	}
-
-	copy(m.data[m.cursor:], other.Data())
-
-	m.cursor += n
-	return nil
+	fit := min(n, caps-cursor)
+	if fit <= 0 {
+		return err
+	}
+	copy(m.data[cursor:], items[:fit])
+	atomic.StoreUint64(&m.cursor, uint64(cursor+fit))
+	return err
 }
 
-// Put
-// 注意, 按需创建, 没有自动扩容
-func (m *MmapUint64) Put(items ...uint64) error {
-	n := uint64(len(items))
-	if n == 0 {
-		return nil
+// Alloc 在末尾预留 n 个元素并返回对应的切片, 用于按索引并发写入
+func (m *MmapUint64) Alloc(n int) ([]uint64, error) {
+	if n < 0 {
+		return nil, errors.New("mmap alloc negative size")
 	}
-	startIdx := atomic.AddUint64(&m.cursor, n) - n
-	if startIdx+n > uint64(len(m.data)) {
-		return errors.New("mmap capacity exceeded")
+	newCount := m.cursor + uint64(n)
+	if err := m.reserve(int(newCount)); err != nil {
+		return nil, err
 	}
-
-	copy(m.data[startIdx:], items)
-	return nil
+	data := m.data[m.cursor:newCount]
+	m.cursor = newCount
+	return data, nil
 }
 
 func (m *MmapUint64) Index(offset int) (uint64, bool) {
@@ -133,6 +151,11 @@ func (m *MmapUint64) Len() int {
 func (m *MmapUint64) Clear() {
 	atomic.StoreUint64(&m.cursor, 0)
 	if len(m.raw) > 0 {
+		if m.store != nil {
+			// 同时释放文件中的磁盘空间
+			unix.Madvise(m.raw, unix.MADV_REMOVE)
+			return
+		}
 		// 释放对应的物理页(RSS)，但保留虚拟地址空间(VIRT)
 		unix.Madvise(m.raw, unix.MADV_DONTNEED)
 	}
@@ -140,7 +163,7 @@ func (m *MmapUint64) Clear() {
 
 func (m *MmapUint64) Destroy() {
 	if len(m.raw) > 0 {
-		_ = unix.Munmap(m.raw)
+		m.unmap()
 		m.raw = nil
 		m.data = nil
 		atomic.StoreUint64(&m.cursor, 0)
@@ -148,10 +171,48 @@ func (m *MmapUint64) Destroy() {
 	}
 }
 
+func (m *MmapUint64) unmap() {
+	if len(m.raw) == 0 {
+		return
+	}
+	if m.store != nil {
+		m.store.unmapSegment(m.raw, m.offset)
+		return
+	}
+	_ = unix.Munmap(m.raw)
+}
+
+// reserve 确保容量至少为 n, 按倍数扩容但不超过 limit
+func (m *MmapUint64) reserve(n int) error {
+	need := n
+	if m.limit > 0 {
+		need = min(n, m.limit)
+	}
+	if need > len(m.data) {
+		caps := max(len(m.data)*2, need)
+		if m.limit > 0 {
+			caps = min(caps, m.limit)
+		}
+		if err := m.grow(caps); err != nil {
+			// 倍数扩容失败时只扩容到需要的大小
+			if caps == need || m.grow(need) != nil {
+				return fmt.Errorf("mmap grow failed: %w", err)
+			}
+		}
+	}
+	if n > need {
+		return fmt.Errorf("%w: limit %d", ErrMmapCapacity, m.limit)
+	}
+	return nil
+}
+
 func (m *MmapUint64) grow(caps int) error {
 	if caps <= len(m.data) {
 		return nil
 	}
+	if m.store != nil {
+		return m.growSegment(caps)
+	}
 
 	size := caps * 8
 
@@ -164,3 +225,18 @@ func (m *MmapUint64) grow(caps int) error {
 	m.data = unsafe.Slice((*uint64)(unsafe.Pointer(&m.raw[0])), caps)
 	return nil
 }
+
+// growSegment 文件中的下一段可能属于其它缓冲区, 不能原地 mremap, 映射新的一段并复制
+func (m *MmapUint64) growSegment(caps int) error {
+	raw, offset, err := m.store.mapSegment(caps)
+	if err != nil {
+		return err
+	}
+	data := unsafe.Slice((*uint64)(unsafe.Pointer(&raw[0])), len(raw)/8)
+	copy(data, m.data[:m.Len()])
+	if len(m.raw) > 0 {
+		m.store.unmapSegment(m.raw, m.offset)
+	}
+	m.raw, m.data, m.offset = raw, data, offset
+	return nil
+}
@@ -0,0 +1,77 @@
+package memscan
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMmapUint64_PutGrow(t *testing.T) {
+	buf, err := NewMmapUint64(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Destroy()
+
+	// 单个 Region 的结果可能远超初始容量, 例如在全零的页面中搜索 0
+	batch := make([]uint64, collectBatchSize)
+	for i := 0; i < 100; i++ {
+		for k := range batch {
+			batch[k] = uint64(i*collectBatchSize + k)
+		}
+		if err = buf.Put(batch...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := buf.Data()
+	if len(data) != 100*collectBatchSize {
+		t.Fatalf("len: got %d", len(data))
+	}
+	for i, v := range data {
+		if v != uint64(i) {
+			t.Fatalf("data[%d]: got %d", i, v)
+		}
+	}
+
+	other, err := NewMmapUint64(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Destroy()
+	if err = other.Merge(buf); err != nil || other.Len() != buf.Len() {
+		t.Fatalf("merge: %v, len %d", err, other.Len())
+	}
+}
+
+func TestMmapUint64_PutLimit(t *testing.T) {
+	// 初始容量大于 limit 时同样受限制
+	buf, err := NewMmapUint64(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Destroy()
+	buf.SetLimit(3)
+
+	if err = buf.Put(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err = buf.Put(3, 4); !errors.Is(err, ErrMmapCapacity) {
+		t.Fatalf("got %v", err)
+	}
+	if err = buf.Put(5); !errors.Is(err, ErrMmapCapacity) {
+		t.Fatalf("got %v", err)
+	}
+	if data := buf.Data(); len(data) != 3 || data[2] != 3 {
+		t.Fatalf("data: got %v", data)
+	}
+
+	if _, err = buf.Alloc(1); !errors.Is(err, ErrMmapCapacity) {
+		t.Fatalf("alloc: got %v", err)
+	}
+
+	p := &scanProgress{}
+	p.put(buf, 6)
+	p.put(buf, 7)
+	if !p.truncated.Load() || len(p.warnings) != 1 {
+		t.Fatalf("truncated %v, warnings %v", p.truncated.Load(), p.warnings)
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	ErrPatchOverlap  = errors.New("patch overlaps an existing patch")
+	ErrPatchNotFound = errors.New("patch not found")
+)
+
+// Patch 写入前的原始数据, RestorePatch 时写回 Original
+type Patch struct {
+	ID       int
+	Address  uint64
+	Original []byte
+	Data     []byte
+	// Forced 页面不可写 (例如代码段, .rodata), 通过 /proc/pid/mem 写入
+	Forced bool
+}
+
+// patcher 属于目标, 与 freezer 一样不随扫描结果重置
+// process_vm_writev 无法写入只读页面, 此时使用 /proc/pid/mem, 它不受页面权限限制
+type patcher struct {
+	mem MemoryAccessor
+	mu  sync.Mutex
+	// procMem 第一次强制写入时打开, mem 本身是 ProcMem 时不使用
+	procMem *ProcMem
+	patches []*Patch
+	nextID  int
+}
+
+func newPatcher(mem MemoryAccessor) *patcher {
+	return &patcher{mem: mem, nextID: 1}
+}
+
+// forced 不受页面权限限制的访问方式, 没有对应的进程时 (例如 FakeMemory) 返回 nil
+func (p *patcher) forced() (MemoryAccessor, error) {
+	if mem, ok := p.mem.(*ProcMem); ok {
+		return mem, nil
+	}
+	if p.procMem != nil {
+		return p.procMem, nil
+	}
+	target, ok := p.mem.(interface{ PID() int })
+	if !ok {
+		return nil, unix.EFAULT
+	}
+	mem, err := NewProcMem(target.PID())
+	if err != nil {
+		return nil, err
+	}
+	p.procMem = mem
+	return mem, nil
+}
+
+// access 先使用 mem, 失败 (EFAULT) 时使用 forced
+func (p *patcher) access(address uint64, buf []byte, write bool) (forced bool, err error) {
+	local := []unix.Iovec{{Base: &buf[0], Len: uint64(len(buf))}}
+	remote := []unix.RemoteIovec{{Base: uintptr(address), Len: len(buf)}}
+	transfer := func(mem MemoryAccessor) (int, error) {
+		if write {
+			return mem.WriteV(local, remote)
+		}
+		return mem.ReadV(local, remote)
+	}
+
+	n, err := transfer(p.mem)
+	if n == len(buf) {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, unix.EFAULT) {
+		return false, err
+	}
+	mem, err := p.forced()
+	if err != nil {
+		return false, err
+	}
+	if n, err = transfer(mem); n == len(buf) {
+		return true, nil
+	}
+	if err == nil {
+		err = unix.EFAULT
+	}
+	return false, err
+}
+
+// write 写入 data 并记录原始数据
+// 同一地址, 相同长度再次写入时保留最初的原始数据
+func (p *patcher) write(address uint64, data []byte) (Patch, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	end := address + uint64(len(data))
+	var patch *Patch
+	for _, other := range p.patches {
+		if address >= other.Address+uint64(len(other.Data)) || end <= other.Address {
+			continue
+		}
+		if other.Address != address || len(other.Data) != len(data) {
+			return Patch{}, ErrPatchOverlap
+		}
+		patch = other
+	}
+
+	if patch == nil {
+		original := make([]byte, len(data))
+		if _, err := p.access(address, original, false); err != nil {
+			return Patch{}, fmt.Errorf("read %08X: %w", address, err)
+		}
+		patch = &Patch{ID: p.nextID, Address: address, Original: original}
+	}
+	forced, err := p.access(address, slices.Clone(data), true)
+	if err != nil {
+		return Patch{}, fmt.Errorf("write %08X: %w", address, err)
+	}
+	patch.Data = slices.Clone(data)
+	patch.Forced = patch.Forced || forced
+	if patch.ID == p.nextID {
+		p.nextID++
+		p.patches = append(p.patches, patch)
+	}
+	return patch.clone(), nil
+}
+
+// restore 写回原始数据, 失败时保留该 Patch
+func (p *patcher) restore(id int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := slices.IndexFunc(p.patches, func(patch *Patch) bool {
+		return patch.ID == id
+	})
+	if i < 0 {
+		return ErrPatchNotFound
+	}
+	patch := p.patches[i]
+	if _, err := p.access(patch.Address, slices.Clone(patch.Original), true); err != nil {
+		return fmt.Errorf("restore %08X: %w", patch.Address, err)
+	}
+	p.patches = slices.Delete(p.patches, i, i+1)
+	return nil
+}
+
+func (p *patcher) list() []Patch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	patches := make([]Patch, len(p.patches))
+	for i, patch := range p.patches {
+		patches[i] = patch.clone()
+	}
+	return patches
+}
+
+// discard 丢弃备份, 不写回
+func (p *patcher) discard() {
+	p.mu.Lock()
+	p.patches = nil
+	p.mu.Unlock()
+}
+
+func (p *patcher) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.patches = nil
+	if p.procMem != nil {
+		_ = p.procMem.Close()
+		p.procMem = nil
+	}
+}
+
+func (patch *Patch) clone() Patch {
+	c := *patch
+	c.Original = slices.Clone(patch.Original)
+	c.Data = slices.Clone(patch.Data)
+	return c
+}
+
+// SetForceWrites 开启后 ChangeValues, ChangeResultsValues 遇到已映射但不可写的页面时
+// 与 WritePatch 一样通过 /proc/pid/mem 写入并记录 Patch, 默认关闭
+func (m *Memscan) SetForceWrites(enabled bool) {
+	m.forceWrites = enabled
+}
+
+func (m *Memscan) ForceWrites() bool {
+	return m.forceWrites
+}
+
+// inReadOnlyRegion [address, address+size) 位于同一个已映射但不可写的 Region 中
+// regions 按地址排列
+func inReadOnlyRegion(regions Regions, address uint64, size int) bool {
+	i, found := slices.BinarySearchFunc(regions, address, func(r Region, addr uint64) int {
+		return cmp.Compare(r.Start, addr)
+	})
+	if !found {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+	r := regions[i]
+	return address+uint64(size) <= r.End && !r.Perm.Write()
+}
+
+// WritePatch 写入 data 并备份原始数据, 页面不可写时 (例如代码段) 通过 /proc/pid/mem 写入
+// 同一地址再次写入相同长度的数据时更新原来的 Patch, 与其它 Patch 部分重叠时返回 ErrPatchOverlap
+func (m *Memscan) WritePatch(address uint64, data []byte) (Patch, error) {
+	if len(data) == 0 {
+		return Patch{}, ErrScanValue
+	}
+	if m.patcher == nil || !m.alive() {
+		return Patch{}, ErrNoProcess
+	}
+	return m.patcher.write(address, data)
+}
+
+// Patches 按写入顺序排列
+func (m *Memscan) Patches() []Patch {
+	if m.patcher == nil {
+		return nil
+	}
+	return m.patcher.list()
+}
+
+// RestorePatch 写回原始数据, 失败时 (例如内存已释放) 保留该 Patch, 由用户决定是否 DiscardPatches
+func (m *Memscan) RestorePatch(id int) error {
+	if m.patcher == nil || !m.alive() {
+		return ErrNoProcess
+	}
+	return m.patcher.restore(id)
+}
+
+// RestorePatches 按写入的相反顺序恢复所有 Patch, 返回第一个错误
+func (m *Memscan) RestorePatches() (err error) {
+	patches := m.Patches()
+	for i := len(patches) - 1; i >= 0; i-- {
+		if e := m.RestorePatch(patches[i].ID); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// DiscardPatches 丢弃所有备份, 内存中的数据保持不变
+func (m *Memscan) DiscardPatches() {
+	if m.patcher != nil {
+		m.patcher.discard()
+	}
+}
@@ -0,0 +1,92 @@
+package memscan
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/kayon/memscan/deck"
+	"github.com/kayon/memscan/scanner"
+)
+
+func TestMemscan_WritePatch(t *testing.T) {
+	if mem, err := NewProcMem(os.Getpid()); err != nil {
+		t.Skip(err)
+	} else {
+		_ = mem.Close()
+	}
+
+	proc, err := deck.NewProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMemscan()
+	if err = m.Open(proc); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// 只读页面, process_vm_writev 返回 EFAULT
+	page, err := unix.Mmap(-1, 0, memPageSize, unix.PROT_READ, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Munmap(page)
+	address := uint64(uintptr(unsafe.Pointer(&page[0])))
+
+	patch, err := m.WritePatch(address, []byte{0x90, 0x90, 0x90, 0x90})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !patch.Forced || !bytes.Equal(page[:4], patch.Data) || !bytes.Equal(patch.Original, make([]byte, 4)) {
+		t.Fatalf("forced patch: %+v, memory % X", patch, page[:4])
+	}
+	again, err := m.WritePatch(address, []byte{1, 2, 3, 4})
+	if err != nil || again.ID != patch.ID || !bytes.Equal(again.Original, patch.Original) {
+		t.Fatalf("patch again: %+v %v", again, err)
+	}
+	if _, err = m.WritePatch(address+2, []byte{5, 6, 7, 8}); !errors.Is(err, ErrPatchOverlap) {
+		t.Fatalf("overlap: got %v", err)
+	}
+
+	// ChangeValues 默认不写入只读页面
+	m.ChangeValues([]uint64{address + 16}, scanner.NewInt32(-1))
+	if !bytes.Equal(page[16:20], make([]byte, 4)) || len(m.Patches()) != 1 {
+		t.Fatalf("change values: % X, %d patches", page[16:20], len(m.Patches()))
+	}
+	// SetForceWrites 之后回退到 /proc/pid/mem, 未映射的地址仍然跳过
+	m.SetForceWrites(true)
+	unmapped, err := unix.Mmap(-1, 0, memPageSize, unix.PROT_READ, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	freed := uint64(uintptr(unsafe.Pointer(&unmapped[0])))
+	_ = unix.Munmap(unmapped)
+	m.ChangeValues([]uint64{address + 16, freed}, scanner.NewInt32(-1))
+	if !bytes.Equal(page[16:20], []byte{0xFF, 0xFF, 0xFF, 0xFF}) || len(m.Patches()) != 2 {
+		t.Fatalf("forced change values: % X, %d patches", page[16:20], len(m.Patches()))
+	}
+
+	// 可写的页面不需要强制写入
+	heap := make([]byte, 8, testHeapSize)
+	patch, err = m.WritePatch(uint64(uintptr(unsafe.Pointer(&heap[0]))), []byte{7})
+	if err != nil || patch.Forced || heap[0] != 7 {
+		t.Fatalf("writable patch: %+v %v", patch, err)
+	}
+
+	if err = m.RestorePatches(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(page[:32], make([]byte, 32)) || heap[0] != 0 || len(m.Patches()) != 0 {
+		t.Fatalf("restore: % X, heap %d, %d patches", page[:32], heap[0], len(m.Patches()))
+	}
+	if err = m.RestorePatch(patch.ID); !errors.Is(err, ErrPatchNotFound) {
+		t.Fatalf("restore again: got %v", err)
+	}
+	runtime.KeepAlive(heap)
+}
@@ -0,0 +1,300 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// 指针文件均为 gzip 压缩, 整数使用 varint 编码
+//
+// 指针表 (.pmap):
+//
+//	"MSPM" version ptrSize
+//	modules: count, {name, path, base, size}
+//	statics: count, {module, start - module.Base, size}
+//	entries: count, {value - prevValue, address}
+//
+// 指针路径 (.pptr):
+//
+//	"MSPP" version ptrSize
+//	paths: count, {module, offset, count, {offset}}
+const (
+	pointerMapMagic   = "MSPM"
+	pointerPathsMagic = "MSPP"
+	pointerFileVer    = 1
+
+	pointerFileMaxString = 4096
+	pointerFileMaxCount  = 1 << 36
+	// pointerLoadCaps 读取指针表时的初始容量, 数量来自文件, 之后按实际读取的数据扩容
+	pointerLoadCaps = 1 << 20
+)
+
+var ErrPointerFile = errors.New("invalid pointer file")
+
+type pointerWriter struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func (pw *pointerWriter) bytes(b []byte) {
+	if pw.err == nil {
+		_, pw.err = pw.w.Write(b)
+	}
+}
+
+func (pw *pointerWriter) uvarint(v uint64) {
+	pw.bytes(pw.buf[:binary.PutUvarint(pw.buf[:], v)])
+}
+
+func (pw *pointerWriter) varint(v int64) {
+	pw.bytes(pw.buf[:binary.PutVarint(pw.buf[:], v)])
+}
+
+func (pw *pointerWriter) string(s string) {
+	pw.uvarint(uint64(len(s)))
+	pw.bytes([]byte(s))
+}
+
+func (pw *pointerWriter) header(magic string, ptrSize int) {
+	pw.bytes([]byte(magic))
+	pw.bytes([]byte{pointerFileVer, byte(ptrSize)})
+}
+
+type pointerReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (pr *pointerReader) uvarint() uint64 {
+	if pr.err != nil {
+		return 0
+	}
+	var v uint64
+	v, pr.err = binary.ReadUvarint(pr.r)
+	return v
+}
+
+func (pr *pointerReader) varint() int64 {
+	if pr.err != nil {
+		return 0
+	}
+	var v int64
+	v, pr.err = binary.ReadVarint(pr.r)
+	return v
+}
+
+func (pr *pointerReader) count() int {
+	n := pr.uvarint()
+	if n > pointerFileMaxCount {
+		pr.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (pr *pointerReader) string() string {
+	n := pr.uvarint()
+	if n > pointerFileMaxString {
+		pr.fail()
+	}
+	if pr.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	_, pr.err = io.ReadFull(pr.r, b)
+	return string(b)
+}
+
+func (pr *pointerReader) fail() {
+	if pr.err == nil {
+		pr.err = ErrPointerFile
+	}
+}
+
+// header 返回指针大小
+func (pr *pointerReader) header(magic string) int {
+	var b [6]byte
+	if _, err := io.ReadFull(pr.r, b[:]); err != nil {
+		pr.err = err
+		return 0
+	}
+	ptrSize := int(b[5])
+	if string(b[:4]) != magic || b[4] != pointerFileVer || (ptrSize != PointerSize32 && ptrSize != PointerSize64) {
+		pr.fail()
+	}
+	return ptrSize
+}
+
+func writeGzip(w io.Writer, fn func(pw *pointerWriter)) error {
+	zw := gzip.NewWriter(w)
+	pw := &pointerWriter{w: bufio.NewWriter(zw)}
+	fn(pw)
+	if pw.err == nil {
+		pw.err = pw.w.Flush()
+	}
+	if err := zw.Close(); pw.err == nil {
+		pw.err = err
+	}
+	return pw.err
+}
+
+func readGzip(r io.Reader, fn func(pr *pointerReader)) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	pr := &pointerReader{r: bufio.NewReader(zr)}
+	fn(pr)
+	if errors.Is(pr.err, io.EOF) || errors.Is(pr.err, io.ErrUnexpectedEOF) {
+		return ErrPointerFile
+	}
+	return pr.err
+}
+
+// Save 模块的静态段保存为相对模块基址的偏移
+func (pm *PointerMap) Save(w io.Writer) error {
+	return writeGzip(w, func(pw *pointerWriter) {
+		pw.header(pointerMapMagic, pm.ptrSize)
+
+		pw.uvarint(uint64(len(pm.modules)))
+		for _, mod := range pm.modules {
+			pw.string(mod.Name)
+			pw.string(mod.Path)
+			pw.uvarint(mod.Base)
+			pw.uvarint(mod.End - mod.Base)
+		}
+
+		pw.uvarint(uint64(len(pm.statics)))
+		for _, r := range pm.statics {
+			pw.uvarint(uint64(r.module))
+			pw.uvarint(r.start - pm.modules[r.module].Base)
+			pw.uvarint(r.end - r.start)
+		}
+
+		pairs := pm.pairs()
+		pw.uvarint(uint64(len(pairs)))
+		var prev uint64
+		for _, p := range pairs {
+			pw.uvarint(p[0] - prev)
+			pw.uvarint(p[1])
+			prev = p[0]
+		}
+	})
+}
+
+// LoadPointerMap 读取 Save 保存的指针表, 可以离线使用 Scan
+func LoadPointerMap(r io.Reader) (*PointerMap, error) {
+	pm := &PointerMap{}
+	err := readGzip(r, func(pr *pointerReader) {
+		pm.ptrSize = pr.header(pointerMapMagic)
+
+		n := pr.count()
+		for i := 0; i < n && pr.err == nil; i++ {
+			mod := Module{Name: pr.string(), Path: pr.string(), Base: pr.uvarint()}
+			mod.End = mod.Base + pr.uvarint()
+			pm.modules = append(pm.modules, mod)
+		}
+
+		n = pr.count()
+		for i := 0; i < n && pr.err == nil; i++ {
+			module := int(pr.uvarint())
+			if module >= len(pm.modules) {
+				pr.fail()
+				break
+			}
+			start := pm.modules[module].Base + pr.uvarint()
+			pm.statics = append(pm.statics, addrRange{start, start + pr.uvarint(), module})
+		}
+
+		n = pr.count()
+		if pr.err != nil {
+			return
+		}
+		if pm.entries, pr.err = NewMmapUint64(max(min(n, pointerLoadCaps)*2, 2)); pr.err != nil {
+			return
+		}
+		var batch [collectBatchSize]uint64
+		var count int
+		var value uint64
+		for i := 0; i < n && pr.err == nil; i++ {
+			value += pr.uvarint()
+			batch[count] = value
+			batch[count+1] = pr.uvarint()
+			count += 2
+			if count == collectBatchSize {
+				_ = pm.entries.Put(batch[:]...)
+				count = 0
+			}
+		}
+		_ = pm.entries.Put(batch[:count]...)
+	})
+	if err != nil {
+		pm.Destroy()
+		return nil, err
+	}
+	return pm, nil
+}
+
+// SavePointerPaths 保存候选路径, 用于之后在新的进程中验证
+func SavePointerPaths(w io.Writer, ptrSize int, paths []PointerPath) error {
+	if ptrSize != PointerSize32 && ptrSize != PointerSize64 {
+		return ErrPointerSize
+	}
+	return writeGzip(w, func(pw *pointerWriter) {
+		pw.header(pointerPathsMagic, ptrSize)
+		pw.uvarint(uint64(len(paths)))
+		for _, p := range paths {
+			pw.string(p.Module)
+			pw.uvarint(p.Offset)
+			pw.uvarint(uint64(len(p.Offsets)))
+			for _, off := range p.Offsets {
+				pw.varint(off)
+			}
+		}
+	})
+}
+
+// LoadPointerPaths 返回指针大小和路径
+func LoadPointerPaths(r io.Reader) (ptrSize int, paths []PointerPath, err error) {
+	err = readGzip(r, func(pr *pointerReader) {
+		ptrSize = pr.header(pointerPathsMagic)
+		n := pr.count()
+		for i := 0; i < n && pr.err == nil; i++ {
+			p := PointerPath{Module: pr.string(), Offset: pr.uvarint()}
+			depth := pr.count()
+			if depth > pointerFileMaxString {
+				pr.fail()
+				break
+			}
+			p.Offsets = make([]int64, 0, depth)
+			for k := 0; k < depth && pr.err == nil; k++ {
+				p.Offsets = append(p.Offsets, pr.varint())
+			}
+			paths = append(paths, p)
+		}
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return ptrSize, paths, nil
+}
@@ -0,0 +1,370 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+const (
+	PointerSize32 = 4
+	PointerSize64 = 8
+)
+
+var (
+	ErrPointerSize = errors.New("pointer size must be 4 or 8")
+	ErrNoProcess   = errors.New("the process does not exist")
+)
+
+// Module 可执行文件或动态库 (包括 Proton 加载的 .exe/.dll)
+// Base 为该文件所有映射中最低的地址
+type Module struct {
+	Name string
+	Path string
+	Base uint64
+	End  uint64
+}
+
+// addrRange 一段可读内存 [start, end), module 为所属模块的索引, -1 表示不属于任何模块
+type addrRange struct {
+	start, end uint64
+	module     int
+}
+
+// PointerMap 进程中所有 "指向可读内存的对齐值" 的索引
+// entries 交替保存 (value, address), 按 value 排序, 用于由目标地址反向查找指针
+type PointerMap struct {
+	ptrSize  int
+	modules  []Module
+	statics  []addrRange // 模块的可写段 (.data/.bss), 其中的指针地址在重启后不变
+	readable []addrRange
+	entries  *MmapUint64
+}
+
+// BuildPointerMap 读取所有可写内存, 记录每个指向可读内存的值
+// ptrSize 为 PointerSize64 或 PointerSize32 (WoW64)
+func (m *Memscan) BuildPointerMap(ptrSize int, args ...bool) (*PointerMap, error) {
+	if ptrSize != PointerSize32 && ptrSize != PointerSize64 {
+		return nil, ErrPointerSize
+	}
+	if m.maps == nil || !m.alive() {
+		return nil, ErrNoProcess
+	}
+
+	var processPaused bool
+	if len(args) > 0 {
+		processPaused = args[0]
+	}
+
+	if !processPaused && m.proc != nil {
+		m.proc.Pause()
+		defer m.proc.Resume()
+	}
+
+	pm := &PointerMap{ptrSize: ptrSize}
+	pm.loadRegions(m.maps.Parse(REGION_ALL), m.maps.Parse(REGION_ALL_RW))
+
+	regions := RegionsOptimize(m.maps.Parse(REGION_ALL_RW))
+	buffers := make([]*MmapUint64, len(regions))
+
+	var wg sync.WaitGroup
+	for index, region := range regions {
+		if err := m.sem.Acquire(m.ctx, 1); err != nil {
+			break // Canceled
+		}
+		wg.Add(1)
+		go m.taskPointerMap(pm, buffers, index, region, &wg)
+	}
+	wg.Wait()
+
+	if err := m.ctx.Err(); err != nil {
+		destroyBuffers(buffers)
+		return nil, err
+	}
+
+	var total int
+	for _, buf := range buffers {
+		if buf != nil {
+			total += buf.Len()
+		}
+	}
+	entries, err := NewMmapUint64(max(total, 2))
+	if err != nil {
+		destroyBuffers(buffers)
+		return nil, err
+	}
+	for _, buf := range buffers {
+		if buf != nil {
+			_ = entries.Merge(buf)
+		}
+	}
+	destroyBuffers(buffers)
+
+	pm.entries = entries
+	pm.sort()
+	return pm, nil
+}
+
+func destroyBuffers(buffers []*MmapUint64) {
+	for _, buf := range buffers {
+		if buf != nil {
+			buf.Destroy()
+		}
+	}
+}
+
+func (m *Memscan) taskPointerMap(pm *PointerMap, buffers []*MmapUint64, regionIndex int, region Region, wg *sync.WaitGroup) {
+	defer m.sem.Release(1)
+	defer wg.Done()
+
+	// 最坏情况下每个对齐位置都是指针
+	buf, err := NewMmapUint64(int(region.Size) / pm.ptrSize * 2)
+	if err != nil {
+		return
+	}
+
+	var batch [collectBatchSize]uint64
+	var count int
+	size := uint64(pm.ptrSize)
+	// 先用整体范围过滤, 大部分值 (例如 0) 不需要二分查找
+	lower, upper := pm.bounds()
+
+	scanBuf := getScanBuffer()
+	_, _ = readRegion(m.mem, region.Start, region.End, scanBuf, func(addr uint64, data []byte) bool {
+		if m.ctx.Err() != nil {
+			return false
+		}
+		// addr 总是页对齐, data 长度为页的整数倍
+		for p := uint64(0); p+size <= uint64(len(data)); p += size {
+			value := decodePointer(data[p:], pm.ptrSize)
+			if value < lower || value >= upper || !pm.isReadable(value) {
+				continue
+			}
+			batch[count] = value
+			batch[count+1] = addr + p
+			count += 2
+			if count == collectBatchSize {
+				_ = buf.Put(batch[:]...)
+				count = 0
+			}
+		}
+		return true
+	})
+	freeScanBuffer(scanBuf)
+
+	if count > 0 {
+		_ = buf.Put(batch[:count]...)
+	}
+
+	if buf.Len() > 0 {
+		buffers[regionIndex] = buf
+	} else {
+		buf.Destroy()
+	}
+}
+
+// moduleTable 由 REGION_ALL 的区域得到的模块列表
+type moduleTable struct {
+	modules []Module
+	byPath  map[string]int
+	// Parse 为模块 ELF 序列中的每个区域 (包括紧随其后的匿名 .bss) 设置相同的 BaseAddr
+	byBase map[uint64]int
+}
+
+func newModuleTable(all Regions) *moduleTable {
+	t := &moduleTable{
+		byPath: make(map[string]int),
+		byBase: make(map[uint64]int),
+	}
+	for _, r := range all {
+		if r.Filename == "" {
+			// 紧随模块的匿名 .bss 也属于该模块
+			if i, ok := t.regionModule(r); ok {
+				t.modules[i].End = max(t.modules[i].End, r.End)
+			}
+			continue
+		}
+		if r.Filename[0] == '[' {
+			continue
+		}
+		i, ok := t.byPath[r.Filename]
+		if !ok {
+			i = len(t.modules)
+			t.byPath[r.Filename] = i
+			t.modules = append(t.modules, Module{
+				Name: filepath.Base(r.Filename),
+				Path: r.Filename,
+				Base: r.Start,
+				End:  r.End,
+			})
+		}
+		t.modules[i].Base = min(t.modules[i].Base, r.Start)
+		t.modules[i].End = max(t.modules[i].End, r.End)
+		if r.Type == REGION_TYPE_EXE || r.Type == REGION_TYPE_CODE {
+			t.byBase[r.BaseAddr] = i
+		}
+	}
+	return t
+}
+
+// regionModule 区域所属的模块, 匿名的 .bss 通过 BaseAddr 确定
+func (t *moduleTable) regionModule(r Region) (int, bool) {
+	i, ok := t.byPath[r.Filename]
+	if !ok && (r.Type == REGION_TYPE_EXE || r.Type == REGION_TYPE_CODE) {
+		i, ok = t.byBase[r.BaseAddr]
+	}
+	return i, ok
+}
+
+// find 按文件名 (忽略大小写, Windows 模块名不区分大小写) 或完整路径查找模块
+func (t *moduleTable) find(name string) (Module, bool) {
+	if i, ok := t.byPath[name]; ok {
+		return t.modules[i], true
+	}
+	for _, mod := range t.modules {
+		if strings.EqualFold(mod.Name, name) {
+			return mod, true
+		}
+	}
+	return Module{}, false
+}
+
+// base 模块名为空时路径的基址是绝对地址
+func (t *moduleTable) base(name string) (uint64, bool) {
+	if name == "" {
+		return 0, true
+	}
+	mod, ok := t.find(name)
+	return mod.Base, ok
+}
+
+// loadRegions all 为 REGION_ALL, rw 为 REGION_ALL_RW
+func (pm *PointerMap) loadRegions(all, rw Regions) {
+	table := newModuleTable(all)
+	pm.modules = table.modules
+
+	for _, r := range all {
+		pm.readable = append(pm.readable, addrRange{r.Start, r.End, -1})
+	}
+	for _, r := range rw {
+		if i, ok := table.regionModule(r); ok {
+			pm.statics = append(pm.statics, addrRange{r.Start, r.End, i})
+		}
+	}
+
+	pm.readable = mergeRanges(pm.readable)
+	slices.SortFunc(pm.statics, func(a, b addrRange) int {
+		return cmp.Compare(a.start, b.start)
+	})
+}
+
+// mergeRanges 合并相邻的范围, 减少二分查找的次数
+func mergeRanges(ranges []addrRange) []addrRange {
+	slices.SortFunc(ranges, func(a, b addrRange) int {
+		return cmp.Compare(a.start, b.start)
+	})
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.start <= merged[n-1].end {
+			merged[n-1].end = max(merged[n-1].end, r.end)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+func findRange(ranges []addrRange, address uint64) (addrRange, bool) {
+	i := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].end > address
+	})
+	if i < len(ranges) && ranges[i].start <= address {
+		return ranges[i], true
+	}
+	return addrRange{}, false
+}
+
+// bounds 所有可读内存的范围 [lower, upper)
+func (pm *PointerMap) bounds() (lower, upper uint64) {
+	if n := len(pm.readable); n > 0 {
+		return pm.readable[0].start, pm.readable[n-1].end
+	}
+	return
+}
+
+func (pm *PointerMap) isReadable(address uint64) bool {
+	_, ok := findRange(pm.readable, address)
+	return ok
+}
+
+// staticModule address 位于模块的可写段时返回模块的索引
+func (pm *PointerMap) staticModule(address uint64) (int, bool) {
+	r, ok := findRange(pm.statics, address)
+	return r.module, ok
+}
+
+func decodePointer(b []byte, ptrSize int) uint64 {
+	if ptrSize == PointerSize64 {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return uint64(binary.LittleEndian.Uint32(b))
+}
+
+// pairs entries 的 (value, address) 视图
+func (pm *PointerMap) pairs() [][2]uint64 {
+	if pm.entries == nil || pm.entries.Len() < 2 {
+		return nil
+	}
+	data := pm.entries.Data()
+	return unsafe.Slice((*[2]uint64)(unsafe.Pointer(&data[0])), len(data)/2)
+}
+
+func (pm *PointerMap) sort() {
+	slices.SortFunc(pm.pairs(), func(a, b [2]uint64) int {
+		if c := cmp.Compare(a[0], b[0]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a[1], b[1])
+	})
+}
+
+func (pm *PointerMap) PointerSize() int {
+	return pm.ptrSize
+}
+
+func (pm *PointerMap) Modules() []Module {
+	return pm.modules
+}
+
+// Count 指针的数量
+func (pm *PointerMap) Count() int {
+	return len(pm.pairs())
+}
+
+func (pm *PointerMap) Destroy() {
+	if pm.entries != nil {
+		pm.entries.Destroy()
+		pm.entries = nil
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+var (
+	ErrPointerPath    = errors.New("invalid pointer path")
+	ErrModuleNotFound = errors.New("module not found")
+	ErrPointerRead    = errors.New("pointer chain could not be read")
+	ErrWritePattern   = errors.New("wildcards cannot be written")
+)
+
+// PointerPath 以模块为基址的多级指针, 例如 game.exe+0x1A2B30 -> +0x18 -> +0x40
+// [Module+Offset] 读取第一级指针, 之后每一级先加上偏移, 最后一个偏移得到目标地址
+// 没有 Offsets 时 Module+Offset 就是目标地址; Module 为空时 Offset 是绝对地址
+type PointerPath struct {
+	Module  string
+	Offset  uint64
+	Offsets []int64
+}
+
+// ParsePointerPath 支持以下格式, 数字均为十六进制, 0x 前缀可选
+//
+//	"game.exe"+1A2B30,18,40
+//	game.exe+1A2B30,18,-8
+//	game.exe+0x1A2B30 -> +0x18 -> +0x40
+//	7FF61A2B30
+func ParsePointerPath(s string) (PointerPath, error) {
+	var p PointerPath
+	var base string
+	var offsets []string
+
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "->") {
+		offsets = strings.Split(s, "->")
+	} else {
+		offsets = strings.Split(s, ",")
+	}
+	base, offsets = strings.TrimSpace(offsets[0]), offsets[1:]
+
+	var offset string
+	if strings.HasPrefix(base, `"`) {
+		end := strings.IndexByte(base[1:], '"')
+		if end < 0 {
+			return p, ErrPointerPath
+		}
+		p.Module = base[1 : end+1]
+		offset = strings.TrimSpace(base[end+2:])
+		if offset != "" {
+			if offset[0] != '+' {
+				return p, ErrPointerPath
+			}
+			offset = offset[1:]
+		}
+	} else if i := strings.LastIndexByte(base, '+'); i >= 0 {
+		p.Module, offset = strings.TrimSpace(base[:i]), base[i+1:]
+	} else if _, err := parseHex(base); err == nil {
+		offset = base
+	} else {
+		p.Module = base
+	}
+	if p.Module == "" && offset == "" {
+		return p, ErrPointerPath
+	}
+	if offset != "" {
+		v, err := parseHex(offset)
+		if err != nil {
+			return p, ErrPointerPath
+		}
+		p.Offset = v
+	}
+
+	for _, field := range offsets {
+		field = strings.TrimSpace(field)
+		negative := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(strings.TrimPrefix(field, "-"), "+")
+		v, err := parseHex(field)
+		if err != nil || v > 1<<63-1 {
+			return p, ErrPointerPath
+		}
+		off := int64(v)
+		if negative {
+			off = -off
+		}
+		p.Offsets = append(p.Offsets, off)
+	}
+	return p, nil
+}
+
+func parseHex(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 2 && (s[:2] == "0x" || s[:2] == "0X") {
+		s = s[2:]
+	}
+	return strconv.ParseUint(s, 16, 64)
+}
+
+func (p PointerPath) String() string {
+	var sb strings.Builder
+	if p.Module == "" {
+		_, _ = fmt.Fprintf(&sb, "0x%X", p.Offset)
+	} else {
+		_, _ = fmt.Fprintf(&sb, "%s+0x%X", p.Module, p.Offset)
+	}
+	for _, off := range p.Offsets {
+		if off < 0 {
+			_, _ = fmt.Fprintf(&sb, " -> -0x%X", -off)
+		} else {
+			_, _ = fmt.Fprintf(&sb, " -> +0x%X", off)
+		}
+	}
+	return sb.String()
+}
+
+// Format 紧凑格式, 例如 "game.exe"+1A2B30,18,40, 可以由 ParsePointerPath 解析
+func (p PointerPath) Format() string {
+	var sb strings.Builder
+	if p.Module == "" {
+		_, _ = fmt.Fprintf(&sb, "%X", p.Offset)
+	} else {
+		_, _ = fmt.Fprintf(&sb, "%q+%X", p.Module, p.Offset)
+	}
+	for _, off := range p.Offsets {
+		if off < 0 {
+			_, _ = fmt.Fprintf(&sb, ",-%X", -off)
+		} else {
+			_, _ = fmt.Fprintf(&sb, ",%X", off)
+		}
+	}
+	return sb.String()
+}
+
+// key 模块名不区分大小写
+func (p PointerPath) key() string {
+	return strings.ToLower(p.Format())
+}
+
+// Modules 当前进程中的模块, 系统库除外
+func (m *Memscan) Modules() []Module {
+	if m.maps == nil {
+		return nil
+	}
+	return newModuleTable(m.maps.Parse(REGION_ALL)).modules
+}
+
+// ModuleAddress 将 address 表示为 module+offset, 不属于任何模块时返回 false
+func (m *Memscan) ModuleAddress(address uint64) (PointerPath, bool) {
+	for _, mod := range m.Modules() {
+		if address >= mod.Base && address < mod.End {
+			return PointerPath{Module: mod.Name, Offset: address - mod.Base}, true
+		}
+	}
+	return PointerPath{}, false
+}
+
+// ResolvePointerPath 按当前进程的模块基址解析, 返回目标地址
+func (m *Memscan) ResolvePointerPath(p PointerPath, ptrSize int) (uint64, error) {
+	if ptrSize != PointerSize32 && ptrSize != PointerSize64 {
+		return 0, ErrPointerSize
+	}
+	if m.maps == nil || !m.alive() {
+		return 0, ErrNoProcess
+	}
+
+	base, ok := newModuleTable(m.maps.Parse(REGION_ALL)).base(p.Module)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrModuleNotFound, p.Module)
+	}
+
+	address := base + p.Offset
+	buf := make([]byte, ptrSize)
+	for _, off := range p.Offsets {
+		failed := false
+		m.readValuesRawFunc([]uint64{address}, ptrSize, buf, func(int) {
+			failed = true
+		})
+		value := decodePointer(buf, ptrSize)
+		if failed || value == 0 {
+			return 0, fmt.Errorf("%w: %X", ErrPointerRead, address)
+		}
+		address = value + uint64(off)
+	}
+	return address, nil
+}
+
+// ReadPointerPath 读取路径指向的值, valueType 决定类型和大小
+func (m *Memscan) ReadPointerPath(p PointerPath, ptrSize int, valueType *scanner.Value) (*scanner.Value, error) {
+	address, err := m.ResolvePointerPath(p, ptrSize)
+	if err != nil {
+		return nil, err
+	}
+
+	value := displayValue(valueType)
+	buf := make([]byte, valueType.Size())
+	failed := false
+	m.readValuesRawFunc([]uint64{address}, len(buf), buf, func(int) {
+		failed = true
+	})
+	if failed {
+		return nil, fmt.Errorf("%w: %X", ErrPointerRead, address)
+	}
+	value.SetBytes(buf)
+	return value, nil
+}
+
+// WritePointerPath 将 value 写入路径指向的地址
+func (m *Memscan) WritePointerPath(p PointerPath, ptrSize int, value *scanner.Value) error {
+	if value.IsPattern() {
+		return ErrWritePattern
+	}
+	address, err := m.ResolvePointerPath(p, ptrSize)
+	if err != nil {
+		return err
+	}
+	n, err := m.writeValues([]uint64{address}, value)
+	if n == 0 && err == nil {
+		err = unix.EFAULT
+	}
+	return err
+}
+
+// MarshalText JSON 中使用 Format 格式
+func (p PointerPath) MarshalText() ([]byte, error) {
+	return []byte(p.Format()), nil
+}
+
+func (p *PointerPath) UnmarshalText(text []byte) error {
+	path, err := ParsePointerPath(string(text))
+	if err != nil {
+		return err
+	}
+	*p = path
+	return nil
+}
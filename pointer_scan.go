@@ -0,0 +1,235 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"context"
+	"sort"
+)
+
+const (
+	defPointerMaxDepth   = 5
+	defPointerMaxOffset  = 0x1000
+	defPointerMaxResults = 10000
+)
+
+// PointerScanOptions 零值使用默认值
+type PointerScanOptions struct {
+	MaxDepth   int    // 最多几级指针, 默认 5
+	MaxOffset  uint64 // 每一级最大偏移, 默认 0x1000
+	MaxResults int    // 默认 10000
+}
+
+func (o *PointerScanOptions) normalize() {
+	if o.MaxDepth <= 0 {
+		o.MaxDepth = defPointerMaxDepth
+	}
+	if o.MaxOffset == 0 {
+		o.MaxOffset = defPointerMaxOffset
+	}
+	if o.MaxResults <= 0 {
+		o.MaxResults = defPointerMaxResults
+	}
+}
+
+// PointerScan 构建指针表并查找指向 target 的路径
+func (m *Memscan) PointerScan(target uint64, ptrSize int, options PointerScanOptions, args ...bool) ([]PointerPath, error) {
+	pm, err := m.BuildPointerMap(ptrSize, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer pm.Destroy()
+	return pm.Scan(m.ctx, target, options), nil
+}
+
+// Scan 从 target 反向查找: 每一级寻找值位于 [t-MaxOffset, t] 的指针,
+// 指针所在地址位于模块的静态段时得到一条路径, 否则以该地址作为下一级的目标
+func (pm *PointerMap) Scan(ctx context.Context, target uint64, options PointerScanOptions) []PointerPath {
+	options.normalize()
+	s := &pointerSearch{
+		pm:      pm,
+		pairs:   pm.pairs(),
+		ctx:     ctx,
+		options: options,
+		offsets: make([]int64, 0, options.MaxDepth),
+		dead:    make(map[uint64]int),
+	}
+	s.walk(target, options.MaxDepth)
+	return s.results
+}
+
+type pointerSearch struct {
+	pm      *PointerMap
+	pairs   [][2]uint64
+	ctx     context.Context
+	options PointerScanOptions
+	offsets []int64
+	results []PointerPath
+	// dead 以剩余深度 depth 搜索过但没有任何结果的地址, 剩余深度不超过它时无需再搜索
+	dead  map[uint64]int
+	steps int
+}
+
+// walk 返回 false 表示结果已满或者取消
+func (s *pointerSearch) walk(target uint64, depth int) bool {
+	if d, ok := s.dead[target]; ok && depth <= d {
+		return true
+	}
+
+	var lower uint64
+	if target > s.options.MaxOffset {
+		lower = target - s.options.MaxOffset
+	}
+	i := sort.Search(len(s.pairs), func(i int) bool {
+		return s.pairs[i][0] >= lower
+	})
+
+	found := len(s.results)
+	for ; i < len(s.pairs) && s.pairs[i][0] <= target; i++ {
+		if s.steps++; s.steps&0xFFF == 0 && s.ctx.Err() != nil {
+			return false
+		}
+		value, address := s.pairs[i][0], s.pairs[i][1]
+		s.offsets = append(s.offsets, int64(target-value))
+
+		if module, ok := s.pm.staticModule(address); ok {
+			s.results = append(s.results, s.path(module, address))
+			if len(s.results) >= s.options.MaxResults {
+				return false
+			}
+		}
+		if depth > 1 && !s.walk(address, depth-1) {
+			return false
+		}
+		s.offsets = s.offsets[:len(s.offsets)-1]
+	}
+
+	if len(s.results) == found {
+		s.dead[target] = depth
+	}
+	return true
+}
+
+// path offsets 是从目标向基址的顺序, 需要反转
+func (s *pointerSearch) path(module int, address uint64) PointerPath {
+	mod := s.pm.modules[module]
+	offsets := make([]int64, len(s.offsets))
+	for i, off := range s.offsets {
+		offsets[len(offsets)-1-i] = off
+	}
+	return PointerPath{
+		Module:  mod.Name,
+		Offset:  address - mod.Base,
+		Offsets: offsets,
+	}
+}
+
+// ValidatePointerPaths 在当前进程中重新解析 paths, 只保留仍然指向 target 的路径
+// 每次游戏重启后对上次保存的候选路径执行一次, 候选会越来越少, 最终只剩下稳定的路径
+func (m *Memscan) ValidatePointerPaths(paths []PointerPath, ptrSize int, target uint64, args ...bool) ([]PointerPath, error) {
+	if ptrSize != PointerSize32 && ptrSize != PointerSize64 {
+		return nil, ErrPointerSize
+	}
+	if m.maps == nil || !m.alive() {
+		return nil, ErrNoProcess
+	}
+
+	var processPaused bool
+	if len(args) > 0 {
+		processPaused = args[0]
+	}
+
+	if !processPaused && m.proc != nil {
+		m.proc.Pause()
+		defer m.proc.Resume()
+	}
+
+	addresses := m.resolvePointerPaths(paths, ptrSize)
+	valid := make([]PointerPath, 0, len(paths))
+	for i, address := range addresses {
+		if address == target {
+			valid = append(valid, paths[i])
+		}
+	}
+	return valid, nil
+}
+
+// resolvePointerPaths 逐级批量读取所有路径, 解析失败的地址为 0
+func (m *Memscan) resolvePointerPaths(paths []PointerPath, ptrSize int) []uint64 {
+	table := newModuleTable(m.maps.Parse(REGION_ALL))
+	addresses := make([]uint64, len(paths))
+	var depth int
+	for i, p := range paths {
+		if base, ok := table.base(p.Module); ok {
+			addresses[i] = base + p.Offset
+			depth = max(depth, len(p.Offsets))
+		}
+	}
+
+	indexes := make([]int, 0, IOV_MAX)
+	pointers := make([]uint64, 0, IOV_MAX)
+	buf := getReadBuffer(IOV_MAX * ptrSize)
+	defer freeReadBuffer(buf)
+
+	flush := func(level int) {
+		var failed [IOV_MAX]bool
+		m.readValuesRawFunc(pointers, ptrSize, buf, func(i int) {
+			failed[i] = true
+		})
+		for k, i := range indexes {
+			value := decodePointer(buf[k*ptrSize:], ptrSize)
+			if failed[k] || value == 0 {
+				addresses[i] = 0
+				continue
+			}
+			addresses[i] = value + uint64(paths[i].Offsets[level])
+		}
+		indexes = indexes[:0]
+		pointers = pointers[:0]
+	}
+
+	for level := 0; level < depth; level++ {
+		for i, p := range paths {
+			if addresses[i] == 0 || level >= len(p.Offsets) {
+				continue
+			}
+			indexes = append(indexes, i)
+			pointers = append(pointers, addresses[i])
+			if len(indexes) == IOV_MAX {
+				flush(level)
+			}
+		}
+		if len(indexes) > 0 {
+			flush(level)
+		}
+	}
+	return addresses
+}
+
+// IntersectPointerPaths 返回 a 中同样存在于 b 的路径, 用于合并不同会话中的扫描结果
+func IntersectPointerPaths(a, b []PointerPath) []PointerPath {
+	set := make(map[string]struct{}, len(b))
+	for _, p := range b {
+		set[p.key()] = struct{}{}
+	}
+	var ret []PointerPath
+	for _, p := range a {
+		if _, ok := set[p.key()]; ok {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,192 @@
+package memscan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newTestPointerMap(t *testing.T, pairs ...[2]uint64) *PointerMap {
+	entries, err := NewMmapUint64(len(pairs)*2 + 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range pairs {
+		_ = entries.Put(p[0], p[1])
+	}
+	pm := &PointerMap{
+		ptrSize: PointerSize64,
+		modules: []Module{{Name: "game.exe", Path: "/games/game.exe", Base: 0x140000000, End: 0x140300000}},
+		statics: []addrRange{{0x140200000, 0x140300000, 0}},
+		entries: entries,
+	}
+	pm.sort()
+	return pm
+}
+
+func TestPointerMap_Scan(t *testing.T) {
+	const target = 0x7000040
+	pm := newTestPointerMap(t,
+		// game.exe+0x201000 -> 0x5000000, [0x5000018] -> 0x7000000, +0x40 = target
+		[2]uint64{0x5000000, 0x140201000},
+		[2]uint64{0x7000000, 0x5000018},
+		// 偏移过大
+		[2]uint64{0x6000000, 0x140202000},
+		// 不在静态段
+		[2]uint64{0x7000000, 0x9000000},
+		// 一级指针
+		[2]uint64{0x7000040, 0x140202008},
+	)
+	defer pm.Destroy()
+
+	got := pm.Scan(context.TODO(), target, PointerScanOptions{MaxDepth: 3, MaxOffset: 0x100})
+	var paths []string
+	for _, p := range got {
+		paths = append(paths, p.String())
+	}
+	want := []string{
+		"game.exe+0x201000 -> +0x18 -> +0x40",
+		"game.exe+0x202008 -> +0x0",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %q, want %q", paths, want)
+	}
+
+	got = pm.Scan(context.TODO(), target, PointerScanOptions{MaxDepth: 1, MaxOffset: 0x100})
+	if len(got) != 1 || got[0].String() != want[1] {
+		t.Errorf("depth 1: got %v", got)
+	}
+
+	got = pm.Scan(context.TODO(), target, PointerScanOptions{MaxDepth: 3, MaxOffset: 0x100, MaxResults: 1})
+	if len(got) != 1 {
+		t.Errorf("max results: got %d paths", len(got))
+	}
+}
+
+func TestPointerPath_String(t *testing.T) {
+	p := PointerPath{Module: "game.exe", Offset: 0x1A2B30, Offsets: []int64{0x18, -0x8, 0x40}}
+	if got := p.String(); got != "game.exe+0x1A2B30 -> +0x18 -> -0x8 -> +0x40" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPointerMap_SaveLoad(t *testing.T) {
+	pm := newTestPointerMap(t,
+		[2]uint64{0x5000000, 0x140201000},
+		[2]uint64{0x7000000, 0x5000018},
+		[2]uint64{0x7000040, 0x140202008},
+	)
+	defer pm.Destroy()
+
+	var buf bytes.Buffer
+	if err := pm.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPointerMap(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.Destroy()
+
+	if !reflect.DeepEqual(loaded.pairs(), pm.pairs()) || !reflect.DeepEqual(loaded.modules, pm.modules) || !reflect.DeepEqual(loaded.statics, pm.statics) {
+		t.Error("loaded map differs")
+	}
+	opts := PointerScanOptions{MaxDepth: 3, MaxOffset: 0x100}
+	if got, want := loaded.Scan(context.TODO(), 0x7000040, opts), pm.Scan(context.TODO(), 0x7000040, opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err = LoadPointerMap(bytes.NewReader(buf.Bytes()[:0])); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestLoadPointerMap_HugeCount(t *testing.T) {
+	// 指针数量为 pointerFileMaxCount, 实际只有一项
+	var buf bytes.Buffer
+	err := writeGzip(&buf, func(pw *pointerWriter) {
+		pw.header(pointerMapMagic, PointerSize64)
+		pw.uvarint(0)
+		pw.uvarint(0)
+		pw.uvarint(pointerFileMaxCount)
+		pw.uvarint(0x1000)
+		pw.uvarint(0x2000)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadPointerMap(&buf); !errors.Is(err, ErrPointerFile) {
+		t.Fatalf("expected ErrPointerFile, got %v", err)
+	}
+}
+
+func TestPointerPaths_SaveLoad(t *testing.T) {
+	paths := []PointerPath{
+		{Module: "game.exe", Offset: 0x1A2B30, Offsets: []int64{0x18, 0x40}},
+		{Module: "engine.dll", Offset: 0x10, Offsets: []int64{-0x8}},
+	}
+	var buf bytes.Buffer
+	if err := SavePointerPaths(&buf, PointerSize32, paths); err != nil {
+		t.Fatal(err)
+	}
+	ptrSize, loaded, err := LoadPointerPaths(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ptrSize != PointerSize32 || !reflect.DeepEqual(loaded, paths) {
+		t.Errorf("got %d %v", ptrSize, loaded)
+	}
+
+	other := []PointerPath{
+		{Module: "GAME.EXE", Offset: 0x1A2B30, Offsets: []int64{0x18, 0x40}},
+		{Module: "engine.dll", Offset: 0x10, Offsets: []int64{0x8}},
+	}
+	if got := IntersectPointerPaths(paths, other); !reflect.DeepEqual(got, paths[:1]) {
+		t.Errorf("intersect: got %v", got)
+	}
+}
+
+func TestParsePointerPath(t *testing.T) {
+	want := PointerPath{Module: "game.exe", Offset: 0x1A2B30, Offsets: []int64{0x18, -0x8, 0x40}}
+	inputs := []string{
+		`"game.exe"+1A2B30,18,-8,40`,
+		`game.exe+0x1A2B30, 0x18, -8, +40`,
+		`game.exe+0x1A2B30 -> +0x18 -> -0x8 -> +0x40`,
+	}
+	for _, s := range inputs {
+		got, err := ParsePointerPath(s)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %v, %v", s, got, err)
+		}
+	}
+	if got := want.Format(); got != `"game.exe"+1A2B30,18,-8,40` {
+		t.Errorf("Format: got %q", got)
+	}
+
+	tests := []struct {
+		s    string
+		want PointerPath
+	}{
+		{`7FF61A2B30`, PointerPath{Offset: 0x7FF61A2B30}},
+		{`7FF61A2B30,10`, PointerPath{Offset: 0x7FF61A2B30, Offsets: []int64{0x10}}},
+		{`"My Game.exe"`, PointerPath{Module: "My Game.exe"}},
+		{`engine.dll`, PointerPath{Module: "engine.dll"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePointerPath(tt.s)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, %v", tt.s, got, err)
+		}
+		if back, _ := ParsePointerPath(got.Format()); !reflect.DeepEqual(back, got) {
+			t.Errorf("%q: round trip got %v", tt.s, back)
+		}
+	}
+
+	for _, s := range []string{``, `"game.exe`, `"game.exe"1A`, `game.exe+XYZ`, `game.exe+10,`, `game.exe+10,18,zz`} {
+		if _, err := ParsePointerPath(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
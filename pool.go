@@ -27,24 +27,41 @@ func freeReadBuffer(buf []byte) {
 	readBufferPool.Put(buf)
 }
 
+var scanBufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, scanBufferSize)
+		return &b
+	},
+}
+
+func getScanBuffer() []byte {
+	return *scanBufferPool.Get().(*[]byte)
+}
+
+func freeScanBuffer(buf []byte) {
+	scanBufferPool.Put(&buf)
+}
+
 var regionReaderPool = sync.Pool{
 	New: func() any {
 		return &RegionReader{}
 	},
 }
 
-func getRegionReader(pid int, start, end uint64) *RegionReader {
+func getRegionReader(mem MemoryAccessor, start, end uint64) *RegionReader {
 	r := regionReaderPool.Get().(*RegionReader)
-	r.pid = pid
+	r.mem = mem
 	r.start = start
 	r.end = end
 	r.size = end - start
 	r.off = 0
+	r.read = 0
+	r.err = nil
 	return r
 }
 
 func freeRegionReader(r *RegionReader) {
-	r.pid = 0
+	r.mem = nil
 	regionReaderPool.Put(r)
 }
 
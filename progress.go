@@ -0,0 +1,159 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval 两次进度回调的最小间隔
+const progressInterval = 100 * time.Millisecond
+
+// ScanProgress 扫描进度
+// Regions 为已完成的任务数, 首次扫描时是 Region, 再次扫描时是一批结果
+// ETA 按已扫描的字节数估算, 开始阶段为 0
+type ScanProgress struct {
+	Regions      int
+	TotalRegions int
+	Bytes        uint64
+	TotalBytes   uint64
+	Matches      uint64
+	Elapsed      time.Duration
+	ETA          time.Duration
+	Done         bool
+}
+
+// ProgressFunc 在扫描的 goroutine 中调用, 不应阻塞
+type ProgressFunc func(p ScanProgress)
+
+// ProgressChan 将进度发送到 ch, ch 已满时丢弃, 最后一次 (Done) 除外
+func ProgressChan(ch chan<- ScanProgress) ProgressFunc {
+	return func(p ScanProgress) {
+		if p.Done {
+			ch <- p
+			return
+		}
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+}
+
+type scanProgress struct {
+	start        time.Time
+	totalRegions int
+	totalBytes   uint64
+	fn           ProgressFunc
+
+	regions  atomic.Int64
+	bytes    atomic.Uint64
+	matches  atomic.Uint64
+	lastEmit atomic.Int64
+	// elapsed 结束时的耗时
+	elapsed atomic.Int64
+	done    atomic.Bool
+
+	// 以下用于 ScanReport
+	read      atomic.Uint64
+	truncated atomic.Bool
+	mu        sync.Mutex
+	failed    int
+	failures  []RegionFailure
+	warnings  []string
+}
+
+// SetProgressFunc 设置扫描进度回调, nil 取消
+// 也可以在其它 goroutine 中轮询 Progress
+func (m *Memscan) SetProgressFunc(fn ProgressFunc) {
+	m.progressFunc = fn
+}
+
+// Progress 当前 (或最后一次) 扫描的进度
+func (m *Memscan) Progress() ScanProgress {
+	if p := m.progress.Load(); p != nil {
+		return p.snapshot()
+	}
+	return ScanProgress{}
+}
+
+// beginProgress 在启动任务之前调用
+func (m *Memscan) beginProgress(totalRegions int, totalBytes uint64) *scanProgress {
+	p := &scanProgress{
+		start:        time.Now(),
+		totalRegions: totalRegions,
+		totalBytes:   totalBytes,
+		fn:           m.progressFunc,
+	}
+	p.lastEmit.Store(p.start.UnixNano())
+	m.progress.Store(p)
+	return p
+}
+
+// endProgress 所有任务结束之后调用, 取消时进度不会达到 100%
+func (m *Memscan) endProgress() {
+	p := m.progress.Load()
+	if p == nil || p.done.Load() {
+		return
+	}
+	p.elapsed.Store(int64(time.Since(p.start)))
+	p.done.Store(true)
+	if p.fn != nil {
+		p.fn(p.snapshot())
+	}
+}
+
+// add 每个任务完成后调用
+func (p *scanProgress) add(bytes uint64, matches int) {
+	if p == nil {
+		return
+	}
+	p.regions.Add(1)
+	p.bytes.Add(bytes)
+	p.matches.Add(uint64(matches))
+
+	if p.fn == nil {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := p.lastEmit.Load()
+	if now-last < int64(progressInterval) || !p.lastEmit.CompareAndSwap(last, now) {
+		return
+	}
+	if !p.done.Load() {
+		p.fn(p.snapshot())
+	}
+}
+
+func (p *scanProgress) snapshot() ScanProgress {
+	ret := ScanProgress{
+		Regions:      int(p.regions.Load()),
+		TotalRegions: p.totalRegions,
+		Bytes:        p.bytes.Load(),
+		TotalBytes:   p.totalBytes,
+		Matches:      p.matches.Load(),
+		Elapsed:      time.Since(p.start),
+		Done:         p.done.Load(),
+	}
+	if ret.Done {
+		ret.Elapsed = time.Duration(p.elapsed.Load())
+	} else if ret.Bytes > 0 && ret.Bytes < ret.TotalBytes {
+		ret.ETA = time.Duration(float64(ret.Elapsed) * float64(ret.TotalBytes-ret.Bytes) / float64(ret.Bytes))
+	}
+	return ret
+}
@@ -0,0 +1,57 @@
+package memscan
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemscan_Progress(t *testing.T) {
+	m := NewMemscan()
+	ch := make(chan ScanProgress, 1)
+	m.SetProgressFunc(ProgressChan(ch))
+
+	if p := m.Progress(); p.TotalRegions != 0 || p.Done {
+		t.Fatalf("before scan: %+v", p)
+	}
+
+	p := m.beginProgress(8, 8*4096)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.add(4096, 2)
+		}()
+	}
+	wg.Wait()
+
+	// 模拟经过的时间, ETA 应约等于已用时间
+	p.start = p.start.Add(-time.Second)
+	progress := m.Progress()
+	if progress.Regions != 4 || progress.Bytes != 4*4096 || progress.Matches != 8 || progress.Done {
+		t.Fatalf("running: %+v", progress)
+	}
+	if progress.ETA < 900*time.Millisecond || progress.ETA > 1100*time.Millisecond {
+		t.Fatalf("ETA: got %v", progress.ETA)
+	}
+
+	m.endProgress()
+	var last ScanProgress
+	for last = range ch {
+		if last.Done {
+			break
+		}
+	}
+	if last.Regions != 4 || last.ETA != 0 || last.Elapsed < time.Second {
+		t.Fatalf("done: %+v", last)
+	}
+	if progress = m.Progress(); !progress.Done || progress.Elapsed != last.Elapsed {
+		t.Fatalf("after scan: %+v", progress)
+	}
+
+	m.Reset()
+	if progress = m.Progress(); progress.Done {
+		t.Fatalf("after reset: %+v", progress)
+	}
+}
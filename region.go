@@ -74,8 +74,8 @@ func (region Region) String() string {
 	return fmt.Sprintf("%08X-%08X %10d %s %-5s %08X %q", region.Start, region.End, region.Size, region.Perm, region.Type, region.BaseAddr, region.Filename)
 }
 
-func (region Region) Pipe(pid int) io.ReadSeekCloser {
-	return getRegionReader(pid, region.Start, region.End)
+func (region Region) Pipe(mem MemoryAccessor) io.ReadSeekCloser {
+	return getRegionReader(mem, region.Start, region.End)
 }
 
 func ParseRegion(line []byte) *Region {
@@ -28,7 +28,11 @@ type RegionReader struct {
 	start, end uint64
 	size       uint64
 	off        uint64
-	pid        int
+	mem        MemoryAccessor
+
+	// read 成功读取的字节数, err 第一个读取错误, 用于 ScanReport
+	read uint64
+	err  error
 
 	// pre-allocation
 	lIov [1]unix.Iovec
@@ -83,9 +87,56 @@ func (r *RegionReader) Read(p []byte) (n int, err error) {
 	r.rIov[0].Base = uintptr(addr)
 	r.rIov[0].Len = int(size)
 
-	n, err = unix.ProcessVMReadv(r.pid, r.lIov[:], r.rIov[:], 0)
+	n, err = r.mem.ReadV(r.lIov[:], r.rIov[:])
 	if n > 0 {
 		r.off += uint64(n)
+		r.read += uint64(n)
+	}
+	if err != nil && r.err == nil {
+		r.err = err
 	}
 	return
 }
+
+// Stat 成功读取的字节数和第一个读取错误 (EFAULT, EPERM, ESRCH)
+// 必须在 Close 之前调用
+func (r *RegionReader) Stat() (read uint64, err error) {
+	return r.read, r.err
+}
+
+// keepOpen 隐藏 RegionReader 的 Close, 扫描器结束后不会放回池中, 之后仍可以调用 Stat
+type keepOpen struct {
+	io.ReadSeeker
+}
+
+// readRegion 按 len(buf) 分块读取 [start, end), 不可读的页面会被跳过
+// fn 接收一段连续可读的数据, 返回 false 终止读取
+// 返回成功读取的字节数和第一个读取错误, 进程不存在 (unix.ESRCH) 时立即返回
+func readRegion(mem MemoryAccessor, start, end uint64, buf []byte, fn func(addr uint64, data []byte) bool) (uint64, error) {
+	r := getRegionReader(mem, start, end)
+	defer r.Close()
+
+	addr := start
+	for addr < end {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if !fn(addr, buf[:n]) {
+				break
+			}
+			addr += uint64(n)
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, unix.ESRCH) {
+			break
+		}
+		// bad address, 跳到下一页
+		addr = (addr + memPageSize) &^ (memPageSize - 1)
+		if _, err = r.Seek(int64(addr-start), io.SeekStart); err != nil {
+			break
+		}
+	}
+	return r.Stat()
+}
@@ -0,0 +1,193 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// maxReportFailures ScanReport.Failures 最多保留的条数, FailedRegions 仍然是总数
+	maxReportFailures = 64
+)
+
+var (
+	ErrScanBuffer = errors.New("results buffer is not allocated")
+	ErrScanValue  = errors.New("invalid scan value")
+	ErrScanRound  = errors.New("no results from the previous round")
+	ErrScanType   = errors.New("scan type does not match the previous round")
+)
+
+// RegionFailure 读取出错的范围
+// 首次扫描时是 Region, 再次扫描时是一批结果的地址范围
+type RegionFailure struct {
+	Start uint64
+	End   uint64
+	// Read 成功读取的字节数, 0 表示整个范围都不可读
+	Read uint64
+	Err  error
+}
+
+// Reason 错误名称, 例如 EFAULT, EPERM, ESRCH
+func (f RegionFailure) Reason() string {
+	var errno unix.Errno
+	if errors.As(f.Err, &errno) {
+		if name := unix.ErrnoName(errno); name != "" {
+			return name
+		}
+	}
+	if f.Err == nil {
+		return ""
+	}
+	return f.Err.Error()
+}
+
+func (f RegionFailure) String() string {
+	return fmt.Sprintf("%08X-%08X %s (%d/%d bytes)", f.Start, f.End, f.Reason(), f.Read, f.End-f.Start)
+}
+
+// ScanReport 一次扫描的结果
+// Err 不为 nil 时扫描没有执行, 结果保持不变
+type ScanReport struct {
+	Matches int
+	// Regions 完成的任务数, 与 ScanProgress.Regions 相同
+	Regions       int
+	FailedRegions int
+	Failures      []RegionFailure
+	// Bytes 实际读取的字节数
+	Bytes uint64
+	// Truncated 结果缓冲区容量不足, 部分结果被丢弃, 原因见 Warnings
+	Truncated bool
+	Warnings  []string
+	Canceled  bool
+	Duration  time.Duration
+	Err       error
+}
+
+func (r *ScanReport) String() string {
+	if r.Err != nil {
+		return r.Err.Error()
+	}
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "%d matches, %d regions", r.Matches, r.Regions)
+	if r.FailedRegions > 0 {
+		_, _ = fmt.Fprintf(&sb, " (%d failed)", r.FailedRegions)
+	}
+	_, _ = fmt.Fprintf(&sb, ", %d bytes, %s", r.Bytes, r.Duration)
+	if r.Truncated {
+		sb.WriteString(", truncated")
+	}
+	if r.Canceled {
+		sb.WriteString(", canceled")
+	}
+	return sb.String()
+}
+
+func errReport(err error) *ScanReport {
+	return &ScanReport{Err: err}
+}
+
+// record 记录一个任务的读取结果
+func (p *scanProgress) record(start, end, read uint64, err error) {
+	if p == nil {
+		return
+	}
+	p.read.Add(read)
+	if err == nil {
+		return
+	}
+	p.mu.Lock()
+	p.failed++
+	if len(p.failures) < maxReportFailures {
+		p.failures = append(p.failures, RegionFailure{Start: start, End: end, Read: read, Err: err})
+	}
+	p.mu.Unlock()
+}
+
+// warn 相同的警告只记录一次
+func (p *scanProgress) warn(format string, args ...any) {
+	if p == nil {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, w := range p.warnings {
+		if w == msg {
+			return
+		}
+	}
+	p.warnings = append(p.warnings, msg)
+}
+
+// truncate err 不为 nil 时结果被截断
+func (p *scanProgress) truncate(err error) {
+	if p == nil || err == nil {
+		return
+	}
+	p.truncated.Store(true)
+	p.warn("results truncated: %v", err)
+}
+
+// put 容量不足时记录结果被截断
+func (p *scanProgress) put(buf *MmapUint64, items ...uint64) {
+	p.truncate(buf.Put(items...))
+}
+
+// report 在 commitResults 和 captureValues 之后调用
+func (m *Memscan) report(st time.Time) *ScanReport {
+	r := m.progressReport(st)
+	r.Matches = m.Count()
+	// 被取消的一轮结果不完整, 在历史中标记
+	if r.Canceled && m.round > 0 {
+		m.step.Canceled = true
+	}
+	return r
+}
+
+// progressReport 不涉及扫描结果的部分, 用于 CaptureSnapshot 等不产生结果的操作
+func (m *Memscan) progressReport(st time.Time) *ScanReport {
+	r := &ScanReport{
+		Canceled: m.ctx.Err() != nil,
+		Duration: time.Since(st),
+	}
+	p := m.progress.Load()
+	if p == nil {
+		return r
+	}
+	r.Regions = int(p.regions.Load())
+	r.Bytes = p.read.Load()
+	r.Truncated = p.truncated.Load()
+
+	p.mu.Lock()
+	r.FailedRegions = p.failed
+	r.Failures = append([]RegionFailure(nil), p.failures...)
+	r.Warnings = append([]string(nil), p.warnings...)
+	p.mu.Unlock()
+
+	for _, f := range r.Failures {
+		if errors.Is(f.Err, unix.ESRCH) {
+			r.Warnings = append(r.Warnings, ErrNoProcess.Error())
+			break
+		}
+	}
+	return r
+}
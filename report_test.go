@@ -0,0 +1,126 @@
+package memscan
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kayon/memscan/deck"
+	"github.com/kayon/memscan/scanner"
+)
+
+// testHeapSize 扫描自身进程时目标必须在堆上, 栈扩容会移动栈上的变量
+// 超过 64 KiB 的 make 总是分配在堆上
+const testHeapSize = 64*1024 + 8
+
+func TestMemscan_ScanReport(t *testing.T) {
+	m := NewMemscan()
+	if report := m.FirstScan(scanner.NewInt32(1)); !errors.Is(report.Err, ErrNoProcess) {
+		t.Fatalf("without process: got %v", report.Err)
+	}
+
+	proc, err := deck.NewProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Open(proc); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// 不会在其它地方出现的值
+	target := make([]int64, 4, testHeapSize/8)
+	for i := range target {
+		target[i] = 0x5EC7_0A11_D00D_F00D
+	}
+	value := scanner.NewInt64(target[0])
+
+	report := m.FirstScan(value, true)
+	if report.Err != nil {
+		t.Fatal(report.Err)
+	}
+	if report.Matches < len(target) || report.Matches != m.Count() {
+		t.Fatalf("matches: got %d, count %d", report.Matches, m.Count())
+	}
+	if report.Regions == 0 || report.Bytes == 0 || report.Canceled || report.Truncated {
+		t.Fatalf("first scan: %+v", report)
+	}
+	for _, f := range report.Failures {
+		if f.Err == nil || f.Read >= f.End-f.Start || f.Reason() == "" {
+			t.Fatalf("failure: %+v", f)
+		}
+	}
+
+	report = m.NextScan(value)
+	if report.Err != nil || report.Matches < len(target) {
+		t.Fatalf("next scan: %+v", report)
+	}
+	cmp, _ := scanner.NewComparison(scanner.Int32, scanner.CompareChanged, nil)
+	if report = m.NextScanCompare(cmp); !errors.Is(report.Err, ErrScanType) {
+		t.Fatalf("compare type: got %v", report.Err)
+	}
+	if report = m.NextScan(nil); !errors.Is(report.Err, ErrScanValue) {
+		t.Fatalf("nil value: got %v", report.Err)
+	}
+
+	m.SetResultsLimit(2)
+	report = m.FirstScan(value, true)
+	if !report.Truncated || report.Matches != 2 || m.Count() != 2 {
+		t.Fatalf("limit: %+v", report)
+	}
+	if len(report.Warnings) == 0 || !strings.Contains(report.Warnings[0], "limit 2") {
+		t.Fatalf("limit warnings: %v", report.Warnings)
+	}
+	runtime.KeepAlive(target)
+}
+
+func TestMemscan_ScanContext(t *testing.T) {
+	proc, err := deck.NewProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMemscan()
+	if err = m.Open(proc); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	target := make([]int32, 64, testHeapSize/4)
+	for i := range target {
+		target[i] = 0x0BAD_CAFE
+	}
+	value := scanner.NewInt32(target[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	report := m.FirstScanContext(ctx, value, true)
+	if report.Err != nil || report.Canceled {
+		t.Fatalf("first scan: %+v", report)
+	}
+	count := m.Count()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	report = m.NextScanContext(canceled, value)
+	if !report.Canceled || report.Matches != m.Count() || m.Count() > count {
+		t.Fatalf("canceled: %+v", report)
+	}
+	history := m.History()
+	if last := history[len(history)-1]; !last.Canceled || last.Scan != valueStep(value).Scan {
+		t.Fatalf("history: %+v", history)
+	}
+
+	// 取消只影响这一次扫描, 上一轮可以恢复
+	if !m.UndoScan() || m.Count() != count {
+		t.Fatalf("undo: got %d, want %d", m.Count(), count)
+	}
+	report = m.NextScan(value)
+	if report.Canceled || report.Matches < len(target) {
+		t.Fatalf("next scan: %+v", report)
+	}
+	runtime.KeepAlive(target)
+}
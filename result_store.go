@@ -0,0 +1,175 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// resultStore 磁盘上的稀疏临时文件, 每个 MmapUint64 映射其中的一段 (MAP_SHARED)
+// 内存紧张时内核把脏页写回文件后即可回收, 不占用 swap
+// 文件创建后立即删除, 进程退出时自动回收
+type resultStore struct {
+	mu   sync.Mutex
+	dir  string
+	file *os.File
+	fd   int
+	// end 下一段的偏移, 只增不减, 所有段释放后归零
+	end  int64
+	live int
+	// closed 不再分配, 最后一段释放后关闭文件
+	closed bool
+}
+
+func newResultStore(dir string) (*resultStore, error) {
+	f, err := os.CreateTemp(dir, "memscan-*.results")
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Remove(f.Name())
+	return &resultStore{dir: dir, file: f, fd: int(f.Fd())}, nil
+}
+
+// alloc 与 NewMmapUint64 相同, 但数据映射到文件
+func (s *resultStore) alloc(caps int) (*MmapUint64, error) {
+	raw, offset, err := s.mapSegment(caps)
+	if err != nil {
+		return nil, err
+	}
+	m := &MmapUint64{
+		data:   unsafe.Slice((*uint64)(unsafe.Pointer(&raw[0])), len(raw)/8),
+		raw:    raw,
+		store:  s,
+		offset: offset,
+	}
+	runtime.SetFinalizer(m, func(obj *MmapUint64) {
+		obj.unmap()
+	})
+	return m, nil
+}
+
+// mapSegment 在文件末尾映射一段至少 caps 个元素的空间, 按页对齐
+func (s *resultStore) mapSegment(caps int) ([]byte, int64, error) {
+	size := (int64(max(caps, 1))*8 + memPageSize - 1) &^ (memPageSize - 1)
+
+	s.mu.Lock()
+	offset := s.end
+	// 稀疏文件, 只有写入的页面占用磁盘
+	if err := s.file.Truncate(offset + size); err != nil {
+		s.mu.Unlock()
+		return nil, 0, err
+	}
+	s.end += size
+	s.live++
+	s.mu.Unlock()
+
+	raw, err := unix.Mmap(s.fd, offset, int(size), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		s.release(offset, size)
+		return nil, 0, err
+	}
+	return raw, offset, nil
+}
+
+// unmapSegment 解除映射并释放磁盘空间
+func (s *resultStore) unmapSegment(raw []byte, offset int64) {
+	_ = unix.Munmap(raw)
+	s.release(offset, int64(len(raw)))
+}
+
+func (s *resultStore) release(offset, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.live--
+	if s.live > 0 {
+		s.punch(offset, size)
+		return
+	}
+	s.end = 0
+	if s.closed {
+		_ = s.file.Close()
+		return
+	}
+	_ = s.file.Truncate(0)
+}
+
+// punch 释放文件中的一段, 之后读取为 0
+func (s *resultStore) punch(offset, size int64) {
+	_ = unix.Fallocate(s.fd, unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, offset, size)
+}
+
+// close 仍在使用的段不受影响
+func (s *resultStore) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.live == 0 {
+		_ = s.file.Close()
+	}
+}
+
+// newBuffer 按 SetResultsStorage 的设置创建结果缓冲区
+func (m *Memscan) newBuffer(caps int) (*MmapUint64, error) {
+	if m.store != nil {
+		return m.store.alloc(caps)
+	}
+	return NewMmapUint64(caps)
+}
+
+// SetResultsStorage dir 不为空时, 之后的结果保存在 dir 中的临时文件里, 而不是匿名内存
+// 适合结果数量巨大 (例如首次扫描 Int32 1) 且内存紧张的情况, 未知初始值扫描的快照不受影响
+// dir 为空时恢复为匿名内存. 当前结果和历史会被清空
+func (m *Memscan) SetResultsStorage(dir string) error {
+	var store *resultStore
+	if dir != "" {
+		var err error
+		if store, err = newResultStore(dir); err != nil {
+			return err
+		}
+	}
+
+	m.Reset()
+	for _, buf := range m.spare {
+		buf.Destroy()
+	}
+	m.spare = nil
+
+	old := m.store
+	m.store = store
+	for _, buf := range []**MmapUint64{&m.results, &m.values, &m.types} {
+		if *buf != nil {
+			(*buf).Destroy()
+		}
+		*buf = m.takeBuffer()
+	}
+	if old != nil {
+		old.close()
+	}
+	return nil
+}
+
+// ResultsStorage SetResultsStorage 的目录, 匿名内存时为空
+func (m *Memscan) ResultsStorage() string {
+	if m.store == nil {
+		return ""
+	}
+	return m.store.dir
+}
@@ -0,0 +1,108 @@
+package memscan
+
+import (
+	"os"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/kayon/memscan/deck"
+	"github.com/kayon/memscan/scanner"
+)
+
+func TestResultStore(t *testing.T) {
+	store, err := newResultStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.close()
+
+	a, err := store.alloc(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := store.alloc(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = b.Put(100, 200)
+
+	// a 扩容后不能覆盖 b 的数据
+	for i := 0; i < 5000; i++ {
+		if err = a.Put(uint64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a.Len() != 5000 || a.Data()[4999] != 4999 {
+		t.Fatalf("a: len %d", a.Len())
+	}
+	if !slices.Equal(b.Data(), []uint64{100, 200}) {
+		t.Fatalf("b: got %v", b.Data())
+	}
+
+	a.Clear()
+	_ = a.Put(7)
+	if !slices.Equal(a.Data(), []uint64{7}) {
+		t.Fatalf("a after clear: got %v", a.Data())
+	}
+
+	a.Destroy()
+	b.Destroy()
+	if store.live != 0 || store.end != 0 {
+		t.Fatalf("live %d, end %d", store.live, store.end)
+	}
+}
+
+func TestMemscan_ResultsStorage(t *testing.T) {
+	proc, err := deck.NewProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMemscan()
+	if err = m.Open(proc); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	target := make([]int64, 8, testHeapSize/8)
+	for i := range target {
+		target[i] = 0x51DE_CA11_0DD5_0000 + int64(i%2)
+	}
+	value := scanner.NewInt64(target[0])
+
+	if report := m.FirstScan(value, true); report.Err != nil {
+		t.Fatal(report.Err)
+	}
+	expected := slices.Clone(m.Results())
+
+	dir := t.TempDir()
+	if err = m.SetResultsStorage(dir); err != nil {
+		t.Fatal(err)
+	}
+	if m.ResultsStorage() != dir || m.results.store == nil {
+		t.Fatal("results are not file backed")
+	}
+	report := m.FirstScan(value, true)
+	if report.Err != nil || report.Truncated {
+		t.Fatalf("first scan: %+v", report)
+	}
+	// 扫描缓冲区中可能残留其它副本, 数量不一定相同
+	results := m.Results()
+	if len(results) < len(target)/2 {
+		t.Fatalf("results: got %d, anonymous %d", len(results), len(expected))
+	}
+	if report = m.NextScan(value); report.Err != nil || m.Count() < len(target)/2 {
+		t.Fatalf("next scan: %+v", report)
+	}
+	if !m.CanUndo() || !m.UndoScan() || m.Count() != len(results) {
+		t.Fatal("undo with file backed results")
+	}
+
+	if err = m.SetResultsStorage(""); err != nil {
+		t.Fatal(err)
+	}
+	if m.results.store != nil {
+		t.Fatal("results are still file backed")
+	}
+	runtime.KeepAlive(target)
+}
@@ -0,0 +1,244 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Compare 扫描的比较方式
+type Compare uint8
+
+const (
+	// CompareEqual 精确值 (默认)
+	CompareEqual Compare = iota
+
+	// CompareChanged 与上一轮相比发生了变化
+	CompareChanged
+	// CompareUnchanged 与上一轮相比未变化
+	CompareUnchanged
+	// CompareIncreased 与上一轮相比增加
+	CompareIncreased
+	// CompareDecreased 与上一轮相比减少
+	CompareDecreased
+	// CompareIncreasedBy 与上一轮相比增加了 N
+	CompareIncreasedBy
+	// CompareDecreasedBy 与上一轮相比减少了 N
+	CompareDecreasedBy
+
+	// CompareNotEqual 不等于
+	CompareNotEqual
+	// CompareGreater 大于
+	CompareGreater
+	// CompareLess 小于
+	CompareLess
+	// CompareBetween 介于两值之间 (闭区间)
+	CompareBetween
+)
+
+func (c Compare) String() string {
+	switch c {
+	case CompareEqual:
+		return "equal"
+	case CompareChanged:
+		return "changed"
+	case CompareUnchanged:
+		return "unchanged"
+	case CompareIncreased:
+		return "increased"
+	case CompareDecreased:
+		return "decreased"
+	case CompareIncreasedBy:
+		return "increased by"
+	case CompareDecreasedBy:
+		return "decreased by"
+	case CompareNotEqual:
+		return "not equal"
+	case CompareGreater:
+		return "greater than"
+	case CompareLess:
+		return "less than"
+	case CompareBetween:
+		return "between"
+	}
+	return "unknown"
+}
+
+// Relative Whether the comparison is made against the previous round
+func (c Compare) Relative() bool {
+	return c >= CompareChanged && c <= CompareDecreasedBy
+}
+
+// Absolute Whether the comparison is made against given values (except Equal)
+func (c Compare) Absolute() bool {
+	return c >= CompareNotEqual && c <= CompareBetween
+}
+
+// NeedsOperand Whether the comparison requires an operand N
+func (c Compare) NeedsOperand() bool {
+	return c == CompareIncreasedBy || c == CompareDecreasedBy
+}
+
+var (
+	ErrComparisonType    = errors.New("comparison requires a numeric type")
+	ErrComparisonOperand = errors.New("comparison requires an operand of the same type")
+	ErrComparisonCompare = errors.New("invalid comparison")
+)
+
+// Comparison 将当前值与上一轮的值进行比较
+// 值以小端序原始位 (uint64, 零扩展) 的形式传入, 由 Type 决定如何解释
+type Comparison struct {
+	typ     Type
+	compare Compare
+	// IncreasedBy / DecreasedBy
+	delta uint64
+	fdiff float64
+}
+
+func NewComparison(typ Type, compare Compare, operand *Value) (*Comparison, error) {
+	if !typ.Numeric() {
+		return nil, ErrComparisonType
+	}
+	if !compare.Relative() {
+		return nil, ErrComparisonCompare
+	}
+	c := &Comparison{
+		typ:     typ,
+		compare: compare,
+	}
+	if compare.NeedsOperand() {
+		if operand == nil || operand.Type() != typ {
+			return nil, ErrComparisonOperand
+		}
+		c.delta = RawBits(operand.Bytes())
+		switch typ {
+		case Float32:
+			c.fdiff = float64(math.Float32frombits(uint32(c.delta)))
+		case Float64:
+			c.fdiff = math.Float64frombits(c.delta)
+		}
+	}
+	return c, nil
+}
+
+func (c *Comparison) Type() Type {
+	return c.typ
+}
+
+func (c *Comparison) Compare() Compare {
+	return c.compare
+}
+
+func (c *Comparison) Size() int {
+	return c.typ.ByteSize()
+}
+
+// Operand IncreasedBy / DecreasedBy 的 N, 其它比较返回 nil
+func (c *Comparison) Operand() *Value {
+	if !c.compare.NeedsOperand() {
+		return nil
+	}
+	var b [8]byte
+	byteOrder.PutUint64(b[:], c.delta)
+	v := &Value{}
+	v.SetType(c.typ)
+	v.SetBytes(b[:c.Size()])
+	return v
+}
+
+// String 例如 "increased", "increased by 10"
+func (c *Comparison) String() string {
+	if operand := c.Operand(); operand != nil {
+		return fmt.Sprintf("%s %s", c.compare, operand.Format())
+	}
+	return c.compare.String()
+}
+
+// Match prev, curr 是同一地址上一轮和本轮的原始位
+func (c *Comparison) Match(prev, curr uint64) bool {
+	switch c.compare {
+	case CompareChanged:
+		return prev != curr
+	case CompareUnchanged:
+		return prev == curr
+	}
+
+	switch c.typ {
+	case Float32:
+		p := float64(math.Float32frombits(uint32(prev)))
+		n := float64(math.Float32frombits(uint32(curr)))
+		return c.matchFloat(p, n, float32Epsilon)
+	case Float64:
+		return c.matchFloat(math.Float64frombits(prev), math.Float64frombits(curr), float64Epsilon)
+	}
+
+	switch c.compare {
+	case CompareIncreased:
+		if c.typ.Unsigned() {
+			return curr > prev
+		}
+		return c.typ.signExtend(curr) > c.typ.signExtend(prev)
+	case CompareDecreased:
+		if c.typ.Unsigned() {
+			return curr < prev
+		}
+		return c.typ.signExtend(curr) < c.typ.signExtend(prev)
+	case CompareIncreasedBy:
+		// 整数按类型宽度回绕
+		return (prev+c.delta)&c.typ.mask() == curr
+	case CompareDecreasedBy:
+		return (prev-c.delta)&c.typ.mask() == curr
+	}
+	return false
+}
+
+func (c *Comparison) matchFloat(prev, curr, epsilon float64) bool {
+	switch c.compare {
+	case CompareIncreased:
+		return curr > prev
+	case CompareDecreased:
+		return curr < prev
+	case CompareIncreasedBy:
+		return math.Abs(curr-prev-c.fdiff) <= epsilon*math.Max(1, math.Abs(c.fdiff))
+	case CompareDecreasedBy:
+		return math.Abs(prev-curr-c.fdiff) <= epsilon*math.Max(1, math.Abs(c.fdiff))
+	}
+	return false
+}
+
+// MatchBytes 同 Match, len(prev) 和 len(curr) 应等于 Size()
+func (c *Comparison) MatchBytes(prev, curr []byte) bool {
+	return c.Match(RawBits(prev), RawBits(curr))
+}
+
+// RawBits 将不超过 8 字节的小端序数据零扩展为 uint64
+func RawBits(b []byte) uint64 {
+	switch len(b) {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(byteOrder.Uint16(b))
+	case 4:
+		return uint64(byteOrder.Uint32(b))
+	case 8:
+		return byteOrder.Uint64(b)
+	}
+	var buf [8]byte
+	copy(buf[:], b)
+	return byteOrder.Uint64(buf[:])
+}
@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestComparison_Match(t *testing.T) {
+	raw := func(v *Value) uint64 {
+		return RawBits(v.Bytes())
+	}
+
+	tests := []struct {
+		name    string
+		typ     Type
+		compare Compare
+		operand *Value
+		prev    *Value
+		curr    *Value
+		want    bool
+	}{
+		{"Changed", Int32, CompareChanged, nil, NewInt32(1), NewInt32(2), true},
+		{"Changed same", Int32, CompareChanged, nil, NewInt32(1), NewInt32(1), false},
+		{"Unchanged", Int16, CompareUnchanged, nil, NewInt16(-7), NewInt16(-7), true},
+		{"Increased signed", Int8, CompareIncreased, nil, NewInt8(-2), NewInt8(1), true},
+		{"Decreased signed", Int32, CompareDecreased, nil, NewInt32(1), NewInt32(-1), true},
+		{"Increased by", Int32, CompareIncreasedBy, NewInt32(5), NewInt32(100), NewInt32(105), true},
+		{"Increased by wrong", Int32, CompareIncreasedBy, NewInt32(5), NewInt32(100), NewInt32(104), false},
+		{"Increased by wraps", Int8, CompareIncreasedBy, NewInt8(1), NewInt8(127), NewInt8(-128), true},
+		{"Decreased by", Int64, CompareDecreasedBy, NewInt64(10), NewInt64(3), NewInt64(-7), true},
+		{"Increased unsigned", Uint32, CompareIncreased, nil, NewUint32(1 << 30), NewUint32(1 << 31), true},
+		{"Decreased unsigned", Uint8, CompareDecreased, nil, NewUint8(200), NewUint8(100), true},
+		{"Decreased unsigned high bit", Uint8, CompareDecreased, nil, NewUint8(100), NewUint8(200), false},
+		{"Increased by unsigned wraps", Uint16, CompareIncreasedBy, NewUint16(2), NewUint16(65535), NewUint16(1), true},
+		{"Float increased", Float32, CompareIncreased, nil, NewFloat32(1.5), NewFloat32(1.75), true},
+		{"Float decreased by", Float64, CompareDecreasedBy, NewFloat64(0.1), NewFloat64(1.0), NewFloat64(0.9), true},
+		{"Float increased by wrong", Float32, CompareIncreasedBy, NewFloat32(1), NewFloat32(1), NewFloat32(2.5), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewComparison(tt.typ, tt.compare, tt.operand)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Match(raw(tt.prev), raw(tt.curr)); got != tt.want {
+				t.Errorf("%s: %s -> %s, got %v, want %v", tt.compare, tt.prev.Format(), tt.curr.Format(), got, tt.want)
+			}
+			if got := c.MatchBytes(tt.prev.Bytes(), tt.curr.Bytes()); got != tt.want {
+				t.Errorf("%s: MatchBytes got %v, want %v", tt.compare, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComparison_Invalid(t *testing.T) {
+	if _, err := NewComparison(Bytes, CompareChanged, nil); err != ErrComparisonType {
+		t.Errorf("Bytes: got %v, want %v", err, ErrComparisonType)
+	}
+	if _, err := NewComparison(Int32, CompareEqual, nil); err != ErrComparisonCompare {
+		t.Errorf("Equal: got %v, want %v", err, ErrComparisonCompare)
+	}
+	if _, err := NewComparison(Int32, CompareIncreasedBy, nil); err != ErrComparisonOperand {
+		t.Errorf("IncreasedBy without operand: got %v, want %v", err, ErrComparisonOperand)
+	}
+	if _, err := NewComparison(Int32, CompareIncreasedBy, NewInt16(1)); err != ErrComparisonOperand {
+		t.Errorf("IncreasedBy with Int16 operand: got %v, want %v", err, ErrComparisonOperand)
+	}
+}
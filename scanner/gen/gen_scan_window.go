@@ -0,0 +1,151 @@
+//go:build ignore
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/template"
+)
+
+const windowTemplateStr = `// Code generated by gen/gen_scan_window.go; DO NOT EDIT.
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"unsafe"
+)
+
+// scan{{.Name}}WindowVectorized 收集满足 (min <= v <= max) != outside 的值
+func scan{{.Name}}WindowVectorized(data []byte, min, max {{.Type}}, outside bool, offset int, collector CollectorFunc) {
+	n := len(data)
+	if n < {{.Size}} {
+		return
+	}
+	base := unsafe.Pointer(&data[0])
+	i := 0
+
+	for ; i <= n-{{.Step}}; i += {{.Step}} {
+		{{- range .Items}}
+		v{{.Index}} := *(*{{$.Type}})(unsafe.Add(base, i+{{.Offset}}))
+		{{- end}}
+
+		{{- range .Items}}
+		if (v{{.Index}} >= min && v{{.Index}} <= max) != outside {
+			collector(offset + i + {{.Offset}})
+		}
+		{{- end}}
+	}
+
+	for ; i <= n-{{.Size}}; i += {{.Size}} {
+		val := *(*{{.Type}})(unsafe.Add(base, i))
+		if (val >= min && val <= max) != outside {
+			collector(offset + i)
+		}
+	}
+}
+
+func scan{{.Name}}Window(ctx context.Context, reader io.Reader, bufSize int, min, max {{.Type}}, outside bool, collector CollectorFunc, options *Options) {
+	var (
+		chunk     = make([]byte, bufSize)
+		offset    = 0
+		seeker, _ = reader.(io.Seeker)
+	)
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		n, err := io.ReadFull(reader, chunk)
+		if n < 0 {
+			n = 0
+		}
+		if n >= {{.Size}} {
+			// 保证处理的长度始终是对齐到 {{.Size}} 字节的
+			processable := (n / {{.Size}}) * {{.Size}}
+			scan{{.Name}}WindowVectorized(chunk[:processable], min, max, outside, offset, collector)
+		}
+		offset += n
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if options != nil {
+				// 如果未读完期望大小, 尝试对齐到下一页
+				nextOffset, ok := options.alignNextPage(seeker, uint64(offset))
+				if ok {
+					offset = int(nextOffset)
+					continue
+				}
+			}
+			break
+		}
+	}
+}
+`
+
+type TypeConfig struct {
+	Name   string
+	Type   string
+	Size   int
+	Factor int
+}
+
+type UnrollItem struct {
+	Index  int
+	Offset int
+}
+
+func main() {
+	configs := []TypeConfig{
+		{Name: "Int8", Type: "int8", Size: 1, Factor: 8},
+		{Name: "Int16", Type: "int16", Size: 2, Factor: 8},
+		{Name: "Int32", Type: "int32", Size: 4, Factor: 8},
+		{Name: "Int64", Type: "int64", Size: 8, Factor: 8},
+		{Name: "Float32", Type: "float32", Size: 4, Factor: 8},
+		{Name: "Float64", Type: "float64", Size: 8, Factor: 8},
+		{Name: "Uint8", Type: "uint8", Size: 1, Factor: 8},
+		{Name: "Uint16", Type: "uint16", Size: 2, Factor: 8},
+		{Name: "Uint32", Type: "uint32", Size: 4, Factor: 8},
+		{Name: "Uint64", Type: "uint64", Size: 8, Factor: 8},
+	}
+
+	tmpl := template.Must(template.New("gen_window").Parse(windowTemplateStr))
+
+	for _, cfg := range configs {
+		items := make([]UnrollItem, cfg.Factor)
+		for i := 0; i < cfg.Factor; i++ {
+			items[i] = UnrollItem{
+				Index:  i,
+				Offset: i * cfg.Size,
+			}
+		}
+
+		fileName := fmt.Sprintf("../scan_%s_window.go", cfg.Type)
+		f, err := os.Create(fileName)
+		if err != nil {
+			panic(fmt.Sprintf("failed to create file %s: %v", fileName, err))
+		}
+
+		data := struct {
+			TypeConfig
+			Step  int
+			Items []UnrollItem
+		}{
+			TypeConfig: cfg,
+			Step:       cfg.Factor * cfg.Size,
+			Items:      items,
+		}
+
+		err = tmpl.Execute(f, data)
+		if err != nil {
+			panic(fmt.Sprintf("failed to execute template for %s: %v", cfg.Name, err))
+		}
+
+		f.Close()
+		fmt.Printf("Generated %s (Factor: %d)\n", fileName, cfg.Factor)
+	}
+}
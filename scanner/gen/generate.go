@@ -1,4 +1,5 @@
 //go:generate go run ./gen_scan_float_vectorized.go
 //go:generate go run ./gen_scan_float_rounded.go
+//go:generate go run ./gen_scan_window.go
 
 package main
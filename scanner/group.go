@@ -0,0 +1,313 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrGroupMembers = errors.New("group requires at least two values")
+	ErrGroupWindow  = errors.New("group values do not fit in the window")
+)
+
+// Group 在 window 字节的窗口内同时匹配多个值, 例如结构体中相邻的 HP, MP, Level
+// 第一个值作为锚点, 结果地址为第一个值的地址, 其它值位于 [地址, 地址+window) 内且互不重叠
+// ordered 为 true 时其它值必须按给定的顺序依次出现在锚点之后
+// 数值类型按自身大小对齐
+type Group struct {
+	members []Value
+	comps   []ValueComparable
+	window  int
+	total   int // 所有值的大小之和
+	ordered bool
+}
+
+func NewGroup(members []*Value, window int, ordered bool) (*Group, error) {
+	if len(members) < 2 {
+		return nil, ErrGroupMembers
+	}
+	g := &Group{
+		members: make([]Value, len(members)),
+		comps:   make([]ValueComparable, len(members)),
+		window:  window,
+		ordered: ordered,
+	}
+	for i, member := range members {
+		if member == nil || member.Size() == 0 {
+			return nil, ErrGroupMembers
+		}
+		g.members[i] = *member
+		g.comps[i] = g.members[i].Comparable()
+		g.total += member.Size()
+	}
+	if g.total > window {
+		return nil, ErrGroupWindow
+	}
+	return g, nil
+}
+
+// ParseGroup 解析 "Int32:100; Int32:50; Float32:3.5" 格式的值列表
+func ParseGroup(s string, window int, ordered bool) (*Group, error) {
+	var members []*Value
+	for _, field := range strings.Split(s, ";") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid group value: %q", field)
+		}
+		typ, ok := ParseType(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("invalid group value type: %q", name)
+		}
+		value := &Value{}
+		value.SetType(typ)
+		if err := value.FromString(strings.TrimSpace(raw)); err != nil {
+			return nil, fmt.Errorf("invalid group value %q: %w", field, err)
+		}
+		members = append(members, value)
+	}
+	return NewGroup(members, window, ordered)
+}
+
+func (g *Group) Members() []*Value {
+	members := make([]*Value, len(g.members))
+	for i := range g.members {
+		members[i] = &g.members[i]
+	}
+	return members
+}
+
+func (g *Group) Window() int {
+	return g.window
+}
+
+func (g *Group) Ordered() bool {
+	return g.ordered
+}
+
+// Size 每个结果需要读取的字节数
+func (g *Group) Size() int {
+	return g.window
+}
+
+func (g *Group) String() string {
+	parts := make([]string, len(g.members))
+	for i := range g.members {
+		parts[i] = fmt.Sprintf("%s:%s", g.members[i].Type(), g.members[i].Format())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (g *Group) alignOf(i int) int {
+	if !g.members[i].Aligned() {
+		return 1
+	}
+	return g.members[i].Size()
+}
+
+// MatchAt b 是从 address 开始的 window 字节
+// 数据末尾不足 window 时所有值必须落在 b 内
+func (g *Group) MatchAt(b []byte, address uint64) bool {
+	if len(b) < g.total {
+		return false
+	}
+	if len(b) > g.window {
+		b = b[:g.window]
+	}
+	if !g.comps[0].EqualBytes(b[:g.members[0].Size()]) {
+		return false
+	}
+	if g.ordered {
+		return g.matchOrdered(b, address)
+	}
+	var buf [256]bool
+	used := buf[:]
+	if len(b) > len(buf) {
+		used = make([]bool, len(b))
+	}
+	used = used[:len(b)]
+	for i := 0; i < g.members[0].Size(); i++ {
+		used[i] = true
+	}
+	return g.matchUnordered(b, address, 1, used)
+}
+
+// matchOrdered 每个值取最早的匹配位置即可
+func (g *Group) matchOrdered(b []byte, address uint64) bool {
+	pos := g.members[0].Size()
+	for i := 1; i < len(g.members); i++ {
+		q, ok := g.find(b, address, i, pos, nil)
+		if !ok {
+			return false
+		}
+		pos = q + g.members[i].Size()
+	}
+	return true
+}
+
+// matchUnordered 回溯, 值的数量通常很少
+func (g *Group) matchUnordered(b []byte, address uint64, i int, used []bool) bool {
+	if i == len(g.members) {
+		return true
+	}
+	size := g.members[i].Size()
+	for pos := 0; ; pos++ {
+		q, ok := g.find(b, address, i, pos, used)
+		if !ok {
+			return false
+		}
+		for k := q; k < q+size; k++ {
+			used[k] = true
+		}
+		if g.matchUnordered(b, address, i+1, used) {
+			return true
+		}
+		for k := q; k < q+size; k++ {
+			used[k] = false
+		}
+		pos = q
+	}
+}
+
+// find 从 pos 开始查找第 i 个值, 跳过 used 中已占用的字节
+func (g *Group) find(b []byte, address uint64, i, pos int, used []bool) (int, bool) {
+	size := g.members[i].Size()
+	align := g.alignOf(i)
+	if r := int((address + uint64(pos)) % uint64(align)); r != 0 {
+		pos += align - r
+	}
+Loop:
+	for q := pos; q+size <= len(b); q += align {
+		for k := q; used != nil && k < q+size; k++ {
+			if used[k] {
+				continue Loop
+			}
+		}
+		if g.comps[i].EqualBytes(b[q : q+size]) {
+			return q, true
+		}
+	}
+	return 0, false
+}
+
+// scan 流式读取, 每块末尾不足 window 的部分保留到下一块
+func (g *Group) scan(s *Scanner, reader io.Reader, collector CollectorFunc, options *Options) {
+	var (
+		anchor  = &g.members[0]
+		size    = anchor.Size()
+		align   = g.alignOf(0)
+		chunk   = make([]byte, s.bufSize+g.window)
+		carry   int
+		offset  int // chunk[0] 对应的偏移
+		seeker  io.Seeker
+		isExact = anchor.mask == nil && !anchor.HasOption() && !anchor.HasCompare()
+	)
+	seeker, _ = reader.(io.Seeker)
+
+	for {
+		if s.ctx.Err() != nil {
+			return
+		}
+		n, err := io.ReadFull(reader, chunk[carry:carry+s.bufSize])
+		if n < 0 {
+			n = 0
+		}
+		data := chunk[:carry+n]
+		// 只在窗口完整的位置上匹配
+		limit := len(data) - g.window + 1
+		if limit > 0 && !g.scanChunk(data, limit, offset, size, align, isExact, collector) {
+			return
+		}
+		if limit < 0 {
+			limit = 0
+		}
+		offset += limit
+		carry = copy(chunk, data[limit:])
+
+		if err != nil {
+			// 数据结束或遇到不可读的间隙, 剩余不足 window 的部分也要匹配
+			if !g.scanTail(chunk[:carry], offset, align, collector) {
+				return
+			}
+			if errors.Is(err, io.EOF) || options == nil {
+				break
+			}
+			// 如果未读完期望大小, 尝试对齐到下一页
+			nextOffset, ok := options.alignNextPage(seeker, uint64(offset+carry))
+			if !ok {
+				break
+			}
+			offset = int(nextOffset)
+			carry = 0
+		}
+	}
+}
+
+// scanTail data 不足 window, 只匹配剩余字节能容纳所有值的位置
+func (g *Group) scanTail(data []byte, offset, align int, collector CollectorFunc) bool {
+	for p := 0; p+g.total <= len(data); p++ {
+		if (offset+p)%align != 0 {
+			continue
+		}
+		if g.MatchAt(data[p:], uint64(offset+p)) && !collector(offset+p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Group) scanChunk(data []byte, limit, offset, size, align int, isExact bool, collector CollectorFunc) bool {
+	anchor := g.members[0].data
+	for p := 0; p < limit; {
+		if isExact {
+			// 锚点为精确值时使用 bytes.Index 快速定位
+			n := bytes.Index(data[p:limit+size-1], anchor)
+			if n < 0 {
+				return true
+			}
+			p += n
+			if r := (offset + p) % align; r != 0 {
+				p += align - r
+				continue
+			}
+		} else if r := (offset + p) % align; r != 0 {
+			p += align - r
+			continue
+		}
+		if p >= limit {
+			return true
+		}
+		if g.MatchAt(data[p:p+g.window], uint64(offset+p)) {
+			if !collector(offset + p) {
+				return false
+			}
+		}
+		if isExact {
+			p++
+		} else {
+			p += align
+		}
+	}
+	return true
+}
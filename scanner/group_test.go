@@ -0,0 +1,162 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGroup_Parse(t *testing.T) {
+	g, err := ParseGroup("Int32:100; int32:50 ;Float32:3.5;", 64, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.String(); got != "Int32:100; Int32:50; Float32:3.5" {
+		t.Errorf("got %q", got)
+	}
+
+	invalid := []string{
+		"Int32:100",
+		"Int32:100; Int33:1",
+		"Int32:100; Int8:300",
+		"Int32:100; 50",
+	}
+	for _, s := range invalid {
+		if _, err := ParseGroup(s, 64, false); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+	if _, err := ParseGroup("Int64:1; Int64:2", 15, false); err != ErrGroupWindow {
+		t.Errorf("window: got %v, want %v", err, ErrGroupWindow)
+	}
+}
+
+func TestGroup_Scan(t *testing.T) {
+	data := make([]byte, 256)
+	put32 := func(off int, v uint32) {
+		byteOrder.PutUint32(data[off:], v)
+	}
+	// 0: 100, 50, 3.5 顺序排列
+	put32(0, 100)
+	put32(8, 50)
+	put32(20, math.Float32bits(3.5))
+	// 64: 100, 3.5, 50 乱序
+	put32(64, 100)
+	put32(68, math.Float32bits(3.5))
+	put32(76, 50)
+	// 128: 100, 50 但 3.5 超出窗口
+	put32(128, 100)
+	put32(132, 50)
+	put32(128+32, math.Float32bits(3.5))
+	// 200: 50 未对齐
+	put32(200, 100)
+	put32(206, 50)
+	put32(212, math.Float32bits(3.5))
+
+	tests := []struct {
+		ordered bool
+		window  int
+		want    []int
+	}{
+		{true, 32, []int{0}},
+		{false, 32, []int{0, 64}},
+		{false, 36, []int{0, 64, 128}},
+	}
+
+	for _, tt := range tests {
+		g, err := ParseGroup("Int32:100; Int32:50; Float32:3.5", tt.window, tt.ordered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := NewGroupScanner(context.TODO(), g)
+		s.bufSize = 16
+		got := s.testScan(&mockReader{data: data, step: 7})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ordered %v window %d: got %v, want %v", tt.ordered, tt.window, got, tt.want)
+		}
+		for _, off := range tt.want {
+			if !g.MatchAt(data[off:off+tt.window], uint64(off)) {
+				t.Errorf("MatchAt %d: got false", off)
+			}
+		}
+	}
+
+	// 同一位置不能同时匹配两个值
+	g, _ := ParseGroup("Int32:100; Int32:50; Int32:50", 32, false)
+	if g.MatchAt(data[0:32], 0) {
+		t.Error("MatchAt: members should not overlap")
+	}
+}
+
+// gapReader [gapStart, gapEnd) 不可读, 需要 Seek 跳过
+type gapReader struct {
+	data             []byte
+	off              int
+	gapStart, gapEnd int
+}
+
+var errGap = errors.New("gap")
+
+func (r *gapReader) Read(p []byte) (int, error) {
+	if r.off >= len(r.data) {
+		return 0, io.EOF
+	}
+	if r.off >= r.gapStart && r.off < r.gapEnd {
+		return 0, errGap
+	}
+	end := len(r.data)
+	if r.off < r.gapStart {
+		end = r.gapStart
+	}
+	n := copy(p, r.data[r.off:end])
+	r.off += n
+	return n, nil
+}
+
+func (r *gapReader) Seek(offset int64, whence int) (int64, error) {
+	r.off = int(offset)
+	return offset, nil
+}
+
+func TestGroup_ScanTail(t *testing.T) {
+	data := make([]byte, 3*0x1000)
+	put := func(off int) {
+		byteOrder.PutUint32(data[off:], 100)
+		byteOrder.PutUint32(data[off+4:], 50)
+		byteOrder.PutUint32(data[off+8:], math.Float32bits(3.5))
+	}
+	// 不可读的页之前和数据末尾, 剩余字节不足 window
+	put(0x1000 - 12)
+	put(len(data) - 12)
+
+	for _, ordered := range []bool{true, false} {
+		g, err := ParseGroup("Int32:100; Int32:50; Float32:3.5", 32, ordered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := NewGroupScanner(context.TODO(), g)
+		s.bufSize = 64
+
+		got := s.testScan(&mockReader{data: data[0x2000:], step: 7})
+		if want := []int{0x1000 - 12}; !reflect.DeepEqual(got, want) {
+			t.Errorf("ordered %v EOF: got %X, want %X", ordered, got, want)
+		}
+		// 最后一个值超出数据末尾
+		if got = s.testScan(&mockReader{data: data[0x2000 : len(data)-1]}); got != nil {
+			t.Errorf("ordered %v truncated: got %X", ordered, got)
+		}
+
+		var gap []int
+		reader := &gapReader{data: data, gapStart: 0x1000, gapEnd: 0x2000}
+		s.ScanCollector(reader, func(offset int) bool {
+			gap = append(gap, offset)
+			return true
+		}, &Options{ExpectedSize: uint64(len(data))})
+		if want := []int{0x1000 - 12, len(data) - 12}; !reflect.DeepEqual(gap, want) {
+			t.Errorf("ordered %v gap: got %X, want %X", ordered, gap, want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package scanner
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"slices"
+)
+
+var ErrMultiEmpty = errors.New("value does not fit any numeric type")
+
+// TypedCollectorFunc 同 CollectorFunc, 并附带匹配的类型
+type TypedCollectorFunc func(offset int, typ Type) bool
+
+// multiTypes 搜索所有数值类型时的顺序, 有符号类型无法表示时使用对应的无符号类型
+var multiTypes = [...][2]Type{
+	{Int8, Uint8},
+	{Int16, Uint16},
+	{Int32, Uint32},
+	{Int64, Uint64},
+	{Float32},
+	{Float64},
+}
+
+// Multi 一次搜索多种数值类型, 每种类型按自身大小对齐
+type Multi struct {
+	values []Value
+	comps  []ValueComparable
+}
+
+// NewMulti 将 s 解析为所有能够表示它的数值类型, option 作用于浮点类型
+func NewMulti(s string, option Option) (*Multi, error) {
+	var values []*Value
+	for _, types := range multiTypes {
+		for _, typ := range types {
+			if typ == Bytes {
+				continue
+			}
+			value := &Value{}
+			value.SetType(typ)
+			if value.FromString(s) != nil {
+				continue
+			}
+			value.WithOption(option)
+			values = append(values, value)
+			break
+		}
+	}
+	if len(values) == 0 {
+		return nil, ErrMultiEmpty
+	}
+	return NewMultiValues(values...), nil
+}
+
+// NewMultiValues 由已有的值构造, 非数值类型以及重复的类型会被忽略
+func NewMultiValues(values ...*Value) *Multi {
+	m := &Multi{}
+	for _, value := range values {
+		if value == nil || !value.Type().Numeric() || m.Value(value.Type()) != nil {
+			continue
+		}
+		m.values = append(m.values, *value)
+		m.comps = append(m.comps, m.values[len(m.values)-1].Comparable())
+	}
+	return m
+}
+
+func (m *Multi) Values() []*Value {
+	values := make([]*Value, len(m.values))
+	for i := range m.values {
+		values[i] = &m.values[i]
+	}
+	return values
+}
+
+// Value 返回 typ 对应的值, 不存在返回 nil
+func (m *Multi) Value(typ Type) *Value {
+	for i := range m.values {
+		if m.values[i].typ == typ {
+			return &m.values[i]
+		}
+	}
+	return nil
+}
+
+// Match b 的长度至少为 typ.ByteSize(), 不包含 typ 时返回 false
+func (m *Multi) Match(typ Type, b []byte) bool {
+	for i := range m.values {
+		if m.values[i].typ == typ {
+			size := typ.ByteSize()
+			return len(b) >= size && m.comps[i].EqualBytes(b[:size])
+		}
+	}
+	return false
+}
+
+// NewMultiScanner 使用 ScanTypedCollector 获取每个结果的类型
+func NewMultiScanner(ctx context.Context, multi *Multi) *Scanner {
+	return &Scanner{
+		ctx:     ctx,
+		bufSize: defScanBufferSize,
+		multi:   multi,
+	}
+}
+
+type typedOffset struct {
+	offset int
+	typ    Type
+}
+
+// scan 每块数据依次运行每种类型的向量化搜索, 结果按偏移排序后再交给 collector
+func (m *Multi) scan(s *Scanner, reader io.Reader, collector TypedCollectorFunc, options *Options) {
+	var (
+		chunk     = make([]byte, s.bufSize)
+		offset    = 0
+		seeker, _ = reader.(io.Seeker)
+		found     []typedOffset
+		current   Type
+	)
+	collect := CollectorFunc(func(offset int) bool {
+		found = append(found, typedOffset{offset, current})
+		return true
+	})
+
+	for {
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		n, err := io.ReadFull(reader, chunk)
+		if n < 0 {
+			n = 0
+		}
+		found = found[:0]
+		for i := range m.values {
+			current = m.values[i].typ
+			m.scanChunk(i, chunk[:n], offset, collect)
+		}
+		slices.SortFunc(found, func(a, b typedOffset) int {
+			if c := cmp.Compare(a.offset, b.offset); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.typ, b.typ)
+		})
+		for _, f := range found {
+			if !collector(f.offset, f.typ) {
+				return
+			}
+		}
+
+		offset += n
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if options != nil {
+				// 如果未读完期望大小, 尝试对齐到下一页
+				nextOffset, ok := options.alignNextPage(seeker, uint64(offset))
+				if ok {
+					offset = int(nextOffset)
+					continue
+				}
+			}
+			break
+		}
+	}
+}
+
+func (m *Multi) scanChunk(i int, data []byte, offset int, collector CollectorFunc) {
+	value := &m.values[i]
+	size := value.Size()
+	// offset 总是对齐到 8 字节 (bufSize 或者页)
+	data = data[:len(data)/size*size]
+	if len(data) == 0 {
+		return
+	}
+
+	if value.HasCompare() {
+		for p := 0; p < len(data); p += size {
+			if m.comps[i].EqualBytes(data[p : p+size]) {
+				collector(offset + p)
+			}
+		}
+		return
+	}
+
+	if value.HasOption() {
+		switch rf := m.comps[i].(type) {
+		case *RoundedFloat32:
+			scanFloat32Vectorized(data, rf.min, rf.max, offset, collector)
+		case *RoundedFloat64:
+			scanFloat64Vectorized(data, rf.min, rf.max, offset, collector)
+		}
+		return
+	}
+
+	x := RawBits(value.data)
+	switch value.typ {
+	case Int8:
+		scanInt8WindowVectorized(data, int8(x), int8(x), false, offset, collector)
+	case Int16:
+		scanInt16WindowVectorized(data, int16(x), int16(x), false, offset, collector)
+	case Int32:
+		scanInt32WindowVectorized(data, int32(x), int32(x), false, offset, collector)
+	case Int64:
+		scanInt64WindowVectorized(data, int64(x), int64(x), false, offset, collector)
+	case Uint8:
+		scanUint8WindowVectorized(data, uint8(x), uint8(x), false, offset, collector)
+	case Uint16:
+		scanUint16WindowVectorized(data, uint16(x), uint16(x), false, offset, collector)
+	case Uint32:
+		scanUint32WindowVectorized(data, uint32(x), uint32(x), false, offset, collector)
+	case Uint64, Float64:
+		// 浮点数精确匹配按位比较, 与 Value.EqualBytes 一致
+		scanUint64WindowVectorized(data, x, x, false, offset, collector)
+	case Float32:
+		scanUint32WindowVectorized(data, uint32(x), uint32(x), false, offset, collector)
+	}
+}
@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMulti_Scan(t *testing.T) {
+	data := make([]byte, 64)
+	byteOrder.PutUint16(data[2:], 1500)
+	byteOrder.PutUint32(data[8:], 1500)
+	byteOrder.PutUint64(data[16:], 1500)
+	byteOrder.PutUint32(data[28:], math.Float32bits(1500))
+	byteOrder.PutUint64(data[32:], math.Float64bits(1500))
+	// 未对齐
+	byteOrder.PutUint32(data[42:], 1500)
+
+	m, err := NewMulti("1500", OptionFloatUnrounded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Value(Int8) != nil || m.Value(Uint8) != nil {
+		t.Error("1500 should not fit in 8 bits")
+	}
+
+	type result struct {
+		offset int
+		typ    Type
+	}
+	var got []result
+	s := NewMultiScanner(context.TODO(), m)
+	s.bufSize = 16
+	s.ScanTypedCollector(&mockReader{data: data, step: 5}, func(offset int, typ Type) bool {
+		got = append(got, result{offset, typ})
+		return true
+	}, nil)
+
+	want := []result{
+		{2, Int16},
+		{8, Int16}, {8, Int32}, {8, Int64},
+		{16, Int16}, {16, Int32}, {16, Int64},
+		{28, Float32},
+		{32, Float64},
+		{42, Int16},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, r := range want {
+		if !m.Match(r.typ, data[r.offset:]) {
+			t.Errorf("Match %d %s: got false", r.offset, r.typ)
+		}
+	}
+}
+
+func TestMulti_Types(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Type
+	}{
+		{"100", []Type{Int8, Int16, Int32, Int64, Float32, Float64}},
+		{"200", []Type{Uint8, Int16, Int32, Int64, Float32, Float64}},
+		{"-40000", []Type{Int32, Int64, Float32, Float64}},
+		{"3.5", []Type{Float32, Float64}},
+	}
+	for _, tt := range tests {
+		m, err := NewMulti(tt.input, OptionFloatUnrounded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []Type
+		for _, v := range m.Values() {
+			got = append(got, v.Type())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.input, got, tt.want)
+		}
+	}
+	if _, err := NewMulti("abc", OptionFloatUnrounded); err != ErrMultiEmpty {
+		t.Errorf("got %v, want %v", err, ErrMultiEmpty)
+	}
+}
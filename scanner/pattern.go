@@ -0,0 +1,185 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var _ ValueComparable = &Pattern{}
+
+var ErrPatternWildcard = errors.New("pattern must contain at least one fixed nibble")
+
+// Pattern 带通配符的字节特征码 (AOB), 例如 "48 8B ?? ?? 05 ?? 00" 或半字节通配 "4?"
+// 匹配条件: b[i] & mask[i] == data[i]
+type Pattern struct {
+	data []byte
+	mask []byte
+	// anchor 是特征码中最长的一段完全确定的字节, 先用 bytes.Index 定位 anchor 再校验整体
+	anchor       []byte
+	anchorOffset int
+}
+
+func newPattern(value Value) *Pattern {
+	p := &Pattern{
+		data: make([]byte, len(value.data)),
+		mask: value.mask,
+	}
+	for i := range value.data {
+		p.data[i] = value.data[i] & p.mask[i]
+	}
+
+	start, best := 0, 0
+	for i := 0; i <= len(p.mask); i++ {
+		if i < len(p.mask) && p.mask[i] == 0xFF {
+			continue
+		}
+		if i-start > best {
+			best = i - start
+			p.anchorOffset = start
+		}
+		start = i + 1
+	}
+	p.anchor = p.data[p.anchorOffset : p.anchorOffset+best]
+	return p
+}
+
+func (p *Pattern) Size() int {
+	return len(p.data)
+}
+
+func (p *Pattern) EqualBytes(b []byte) bool {
+	if len(b) < len(p.data) {
+		return false
+	}
+	for i, m := range p.mask {
+		if b[i]&m != p.data[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Pattern) DisturbingByte() byte {
+	return ^p.data[0]
+}
+
+// Index 返回 b 中第一个匹配的位置, 不存在返回 -1
+func (p *Pattern) Index(b []byte) int {
+	size := len(p.data)
+	if len(p.anchor) == 0 {
+		// 没有完整的确定字节 (全部为半字节通配), 逐字节校验
+		for i := 0; i+size <= len(b); i++ {
+			if p.EqualBytes(b[i : i+size]) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	i := p.anchorOffset
+	// anchor 之后至少还需要的字节数
+	tail := size - p.anchorOffset
+	for i+tail <= len(b) {
+		n := bytes.Index(b[i:len(b)-tail+len(p.anchor)], p.anchor)
+		if n < 0 {
+			return -1
+		}
+		pos := i + n - p.anchorOffset
+		if p.EqualBytes(b[pos : pos+size]) {
+			return pos
+		}
+		i += n + 1
+	}
+	return -1
+}
+
+// parsePattern 解析十六进制字符串, "?" 表示通配的半字节, 单独的 "?" 等同于 "??"
+// 不含通配符时 mask 为 nil
+func parsePattern(s string) (data, mask []byte, err error) {
+	fields := strings.Fields(s)
+	for i, field := range fields {
+		if field == "?" {
+			fields[i] = "??"
+		}
+	}
+	s = strings.ToLower(strings.Join(fields, ""))
+	if len(s) == 0 || len(s)%2 != 0 {
+		return nil, nil, fmt.Errorf("invalid hex string: %q", s)
+	}
+
+	n := len(s) / 2
+	data = make([]byte, n)
+	mask = make([]byte, n)
+	wildcard, fixed := false, false
+	for i := 0; i < n; i++ {
+		for j := 0; j < 2; j++ {
+			shift := 4 * (1 - j)
+			c := s[i*2+j]
+			if c == '?' {
+				wildcard = true
+				continue
+			}
+			x, ok := fromHexChar(c)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid hex string: %q", s)
+			}
+			fixed = true
+			data[i] |= x << shift
+			mask[i] |= 0x0F << shift
+		}
+	}
+	if !fixed {
+		return nil, nil, ErrPatternWildcard
+	}
+	if !wildcard {
+		mask = nil
+	}
+	return
+}
+
+func fromHexChar(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	}
+	return 0, false
+}
+
+// formatPattern 与 Value.String 相同的格式, 通配的半字节显示为 "?"
+func formatPattern(data, mask []byte) string {
+	const digits = "0123456789ABCDEF"
+	var sb strings.Builder
+	sb.Grow(len(data) * 3)
+	for i := range data {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		for _, shift := range [2]int{4, 0} {
+			if mask[i]>>shift&0x0F == 0 {
+				sb.WriteByte('?')
+			} else {
+				sb.WriteByte(digits[data[i]>>shift&0x0F])
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPattern_Parse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		pattern bool
+		wantErr bool
+	}{
+		{"48 8B ?? ?? 05 ?? 00", "48 8B ?? ?? 05 ?? 00", true, false},
+		{"488b????05", "48 8B ?? ?? 05", true, false},
+		{"48 ? 4? ?b", "48 ?? 4? ?B", true, false},
+		{"FF 01", "FF 01", false, false},
+		{"?? ??", "", false, true},
+		{"4", "", false, true},
+		{"4G", "", false, true},
+		{"", "", false, true},
+	}
+	for _, tt := range tests {
+		v, err := NewPattern(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if v.IsPattern() != tt.pattern {
+			t.Errorf("%q: IsPattern %v, want %v", tt.input, v.IsPattern(), tt.pattern)
+		}
+		if got := v.String(); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPattern_Scan(t *testing.T) {
+	data := []byte{
+		0x48, 0x8B, 0x11, 0x22, 0x05, 0x33, 0x00, 0x90, // 0: match
+		0x48, 0x8B, 0x11, 0x22, 0x06, 0x33, 0x00, 0x90, // 8: 05 mismatch
+		0x90, 0x48, 0x8B, 0xAA, 0xBB, 0x05, 0xCC, 0x00, // 17: match, unaligned
+		0x48, 0x8B, 0x00, 0x00, 0x05, 0x00, // tail: too short
+	}
+
+	tests := []struct {
+		pattern string
+		step    int
+		want    []int
+	}{
+		{"48 8B ?? ?? 05 ?? 00", 0, []int{0, 17}},
+		{"48 8B ?? ?? 05 ?? 00", 3, []int{0, 17}},
+		{"?? 8B ?? ?? 05", 5, []int{0, 17, 24}},
+		{"4? 8? ?? ?? 0?", 0, []int{0, 8, 17, 24}},
+		{"22 0? 33", 0, []int{3, 11}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			v, err := NewPattern(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := NewScannerBuffer(context.TODO(), *v, 8)
+			got := s.testScan(&mockReader{data: data, step: tt.step})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scan: got %v, want %v", got, tt.want)
+			}
+
+			comp := v.Comparable()
+			for _, i := range tt.want {
+				if !comp.EqualBytes(data[i : i+comp.Size()]) {
+					t.Errorf("EqualBytes at %d: got false", i)
+				}
+			}
+		})
+	}
+}
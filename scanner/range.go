@@ -0,0 +1,186 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package scanner
+
+import (
+	"io"
+	"math"
+)
+
+var _ ValueComparable = &Range{}
+
+// Range 将 NotEqual, Greater, Less, Between 统一为闭区间 [min, max]
+// outside 为 true 时匹配区间以外的值 (NotEqual)
+type Range struct {
+	typ     Type
+	outside bool
+	// 区间为空时不匹配任何值, 例如 Int8 > 127
+	empty bool
+	imin  int64
+	imax  int64
+	umin  uint64
+	umax  uint64
+	fmin  float64
+	fmax  float64
+	raw   []byte
+}
+
+func newRange(value Value) *Range {
+	typ := value.Type()
+	r := &Range{
+		typ: typ,
+		raw: make([]byte, typ.ByteSize()),
+	}
+	copy(r.raw, value.data)
+
+	switch {
+	case typ.Float():
+		r.setFloat(value)
+	case typ.Unsigned():
+		r.setUint(value)
+	default:
+		r.setInt(value)
+	}
+	return r
+}
+
+func (r *Range) setInt(value Value) {
+	typ := value.Type()
+	lo, hi := typ.minInt(), typ.maxInt()
+	x := typ.signExtend(RawBits(value.data))
+
+	switch value.compare {
+	case CompareNotEqual:
+		r.outside = true
+		r.imin, r.imax = x, x
+	case CompareGreater:
+		r.empty = x == hi
+		r.imin, r.imax = x+1, hi
+	case CompareLess:
+		r.empty = x == lo
+		r.imin, r.imax = lo, x-1
+	case CompareBetween:
+		y := typ.signExtend(RawBits(value.upper))
+		r.imin, r.imax = min(x, y), max(x, y)
+	}
+}
+
+func (r *Range) setUint(value Value) {
+	hi := value.typ.mask()
+	x := RawBits(value.data)
+
+	switch value.compare {
+	case CompareNotEqual:
+		r.outside = true
+		r.umin, r.umax = x, x
+	case CompareGreater:
+		r.empty = x == hi
+		r.umin, r.umax = x+1, hi
+	case CompareLess:
+		r.empty = x == 0
+		r.umin, r.umax = 0, x-1
+	case CompareBetween:
+		y := RawBits(value.upper)
+		r.umin, r.umax = min(x, y), max(x, y)
+	}
+}
+
+func (r *Range) setFloat(value Value) {
+	x := value.float()
+
+	switch value.compare {
+	case CompareNotEqual:
+		r.outside = true
+		r.fmin, r.fmax = x, x
+	case CompareGreater:
+		r.fmin, r.fmax = r.nextAfter(x, math.Inf(1)), math.Inf(1)
+		r.empty = math.IsInf(x, 1) || math.IsNaN(x)
+	case CompareLess:
+		r.fmin, r.fmax = math.Inf(-1), r.nextAfter(x, math.Inf(-1))
+		r.empty = math.IsInf(x, -1) || math.IsNaN(x)
+	case CompareBetween:
+		upper := Value{typ: value.typ, data: value.upper}
+		y := upper.float()
+		r.fmin, r.fmax = math.Min(x, y), math.Max(x, y)
+	}
+}
+
+// nextAfter 按类型精度取 x 之后的下一个浮点数
+func (r *Range) nextAfter(x, y float64) float64 {
+	if r.typ == Float32 {
+		return float64(math.Nextafter32(float32(x), float32(y)))
+	}
+	return math.Nextafter(x, y)
+}
+
+func (r *Range) Size() int {
+	return r.typ.ByteSize()
+}
+
+// EqualBytes 是否满足比较条件
+func (r *Range) EqualBytes(b []byte) bool {
+	if r.empty {
+		return false
+	}
+	var in bool
+	switch r.typ {
+	case Float32:
+		v := float64(math.Float32frombits(byteOrder.Uint32(b)))
+		in = v >= r.fmin && v <= r.fmax
+	case Float64:
+		v := math.Float64frombits(byteOrder.Uint64(b))
+		in = v >= r.fmin && v <= r.fmax
+	case Uint8, Uint16, Uint32, Uint64:
+		v := RawBits(b)
+		in = v >= r.umin && v <= r.umax
+	default:
+		v := r.typ.signExtend(RawBits(b))
+		in = v >= r.imin && v <= r.imax
+	}
+	return in != r.outside
+}
+
+func (r *Range) DisturbingByte() byte {
+	return ^r.raw[0]
+}
+
+func (r *Range) scan(s *Scanner, reader io.Reader, collector CollectorFunc, options *Options) {
+	if r.empty {
+		return
+	}
+	switch r.typ {
+	case Int8:
+		scanInt8Window(s.ctx, reader, s.bufSize, int8(r.imin), int8(r.imax), r.outside, collector, options)
+	case Int16:
+		scanInt16Window(s.ctx, reader, s.bufSize, int16(r.imin), int16(r.imax), r.outside, collector, options)
+	case Int32:
+		scanInt32Window(s.ctx, reader, s.bufSize, int32(r.imin), int32(r.imax), r.outside, collector, options)
+	case Int64:
+		scanInt64Window(s.ctx, reader, s.bufSize, r.imin, r.imax, r.outside, collector, options)
+	case Float32:
+		scanFloat32Window(s.ctx, reader, s.bufSize, float32(r.fmin), float32(r.fmax), r.outside, collector, options)
+	case Float64:
+		scanFloat64Window(s.ctx, reader, s.bufSize, r.fmin, r.fmax, r.outside, collector, options)
+	case Uint8:
+		scanUint8Window(s.ctx, reader, s.bufSize, uint8(r.umin), uint8(r.umax), r.outside, collector, options)
+	case Uint16:
+		scanUint16Window(s.ctx, reader, s.bufSize, uint16(r.umin), uint16(r.umax), r.outside, collector, options)
+	case Uint32:
+		scanUint32Window(s.ctx, reader, s.bufSize, uint32(r.umin), uint32(r.umax), r.outside, collector, options)
+	case Uint64:
+		scanUint64Window(s.ctx, reader, s.bufSize, r.umin, r.umax, r.outside, collector, options)
+	}
+}
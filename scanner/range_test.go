@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRange_Scan(t *testing.T) {
+	int32s := []int32{-5, 0, 7, 100, 101, math.MaxInt32, math.MinInt32, 99}
+	int32Data := make([]byte, len(int32s)*4)
+	for i, v := range int32s {
+		byteOrder.PutUint32(int32Data[i*4:], uint32(v))
+	}
+
+	floats := []float32{-1.5, 0, 2.5, 99.9, 100, 100.1, float32(math.Inf(1))}
+	floatData := make([]byte, len(floats)*4)
+	for i, v := range floats {
+		byteOrder.PutUint32(floatData[i*4:], math.Float32bits(v))
+	}
+
+	uint32s := []uint32{5, 1 << 31, math.MaxUint32, 100, 0}
+	uint32Data := make([]byte, len(uint32s)*4)
+	for i, v := range uint32s {
+		byteOrder.PutUint32(uint32Data[i*4:], v)
+	}
+
+	uint8Data := []byte{0, 127, 128, 200, 255, 1}
+
+	between := func(lower, upper *Value) *Value {
+		lower.WithBetween(upper)
+		return lower
+	}
+	compare := func(v *Value, c Compare) *Value {
+		v.WithCompare(c)
+		return v
+	}
+
+	tests := []struct {
+		name  string
+		value *Value
+		data  []byte
+		want  []int
+	}{
+		{"Int32 Greater", compare(NewInt32(99), CompareGreater), int32Data, []int{12, 16, 20}},
+		{"Int32 Greater Max", compare(NewInt32(math.MaxInt32), CompareGreater), int32Data, nil},
+		{"Int32 Less", compare(NewInt32(0), CompareLess), int32Data, []int{0, 24}},
+		{"Int32 Less Min", compare(NewInt32(math.MinInt32), CompareLess), int32Data, nil},
+		{"Int32 NotEqual", compare(NewInt32(0), CompareNotEqual), int32Data, []int{0, 8, 12, 16, 20, 24, 28}},
+		{"Int32 Between", between(NewInt32(101), NewInt32(7)), int32Data, []int{8, 12, 16, 28}},
+		{"Float32 Greater", compare(NewFloat32(100), CompareGreater), floatData, []int{20, 24}},
+		{"Float32 Less", compare(NewFloat32(0), CompareLess), floatData, []int{0}},
+		{"Float32 Between", between(NewFloat32(2.5), NewFloat32(100)), floatData, []int{8, 12, 16}},
+		{"Uint32 Greater", compare(NewUint32(math.MaxInt32), CompareGreater), uint32Data, []int{4, 8}},
+		{"Uint32 Greater Max", compare(NewUint32(math.MaxUint32), CompareGreater), uint32Data, nil},
+		{"Uint32 Less", compare(NewUint32(100), CompareLess), uint32Data, []int{0, 16}},
+		{"Uint32 Less Zero", compare(NewUint32(0), CompareLess), uint32Data, nil},
+		{"Uint32 Between", between(NewUint32(math.MaxUint32), NewUint32(100)), uint32Data, []int{4, 8, 12}},
+		{"Uint8 Greater", compare(NewUint8(127), CompareGreater), uint8Data, []int{2, 3, 4}},
+		{"Uint8 NotEqual", compare(NewUint8(255), CompareNotEqual), uint8Data, []int{0, 1, 2, 3, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScannerBuffer(context.TODO(), *tt.value, 8)
+			got := s.testScan(&mockReader{data: tt.data, step: 12})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scan: got %v, want %v", got, tt.want)
+			}
+
+			comp := tt.value.Comparable()
+			var matched []int
+			for i := 0; i+comp.Size() <= len(tt.data); i += comp.Size() {
+				if comp.EqualBytes(tt.data[i : i+comp.Size()]) {
+					matched = append(matched, i)
+				}
+			}
+			if !reflect.DeepEqual(matched, tt.want) {
+				t.Errorf("EqualBytes: got %v, want %v", matched, tt.want)
+			}
+		})
+	}
+}
+
+func TestRange_WithCompare(t *testing.T) {
+	v := NewBytes([]byte{1, 2})
+	v.WithCompare(CompareGreater)
+	if v.HasCompare() {
+		t.Error("Bytes should not accept compare")
+	}
+
+	i := NewInt16(1)
+	i.WithBetween(NewInt32(2))
+	if i.HasCompare() {
+		t.Error("Between with mismatched upper type should be ignored")
+	}
+
+	i.WithBetween(NewInt16(2))
+	if i.Compare() != CompareBetween || i.Upper().Format() != "2" {
+		t.Errorf("Between: got %s %v", i.Compare(), i.Upper())
+	}
+
+	i.WithCompare(CompareEqual)
+	if i.HasCompare() || i.Upper() != nil {
+		t.Error("CompareEqual should reset the comparison")
+	}
+}
+
+func TestValue_Unsigned(t *testing.T) {
+	tests := []struct {
+		typ   Type
+		input string
+		want  string
+	}{
+		{Uint8, "200", "200"},
+		{Uint16, "65535", "65535"},
+		{Uint32, "3000000000", "3000000000"},
+		{Uint64, "18446744073709551615", "18446744073709551615"},
+	}
+	for _, tt := range tests {
+		v := &Value{}
+		v.SetType(tt.typ)
+		if err := v.FromString(tt.input); err != nil {
+			t.Fatalf("%s: %v", tt.typ, err)
+		}
+		if got := v.Format(); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.typ, got, tt.want)
+		}
+	}
+
+	v := &Value{}
+	v.SetType(Uint8)
+	if err := v.FromString("-1"); err == nil {
+		t.Error("Uint8 should reject negative values")
+	}
+	if got := NewUint8(200).Format(Int8); got != "-56" {
+		t.Errorf("Uint8 as Int8: got %s, want -56", got)
+	}
+}
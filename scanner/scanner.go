@@ -32,6 +32,10 @@ type Scanner struct {
 	bufSize int
 	rf32    *RoundedFloat32
 	rf64    *RoundedFloat64
+	rng     *Range
+	pat     *Pattern
+	group   *Group
+	multi   *Multi
 }
 
 func NewScanner(ctx context.Context, value Value) *Scanner {
@@ -48,7 +52,16 @@ func NewScannerBuffer(ctx context.Context, value Value, bufSize int) *Scanner {
 		value: value,
 	}
 
-	if value.HasOption() {
+	if value.HasCompare() {
+		scan.rng = newRange(value)
+		// 与展开的向量化步长对齐
+		vectorSize := value.Size() * 8
+		if bufSize < vectorSize {
+			bufSize = vectorSize
+		} else if bufSize%vectorSize != 0 {
+			bufSize = ((bufSize + vectorSize - 1) / vectorSize) * vectorSize
+		}
+	} else if value.HasOption() {
 		switch value.Type() {
 		case Float32:
 			scan.rf32 = newRoundedFloat32(value)
@@ -66,6 +79,9 @@ func NewScannerBuffer(ctx context.Context, value Value, bufSize int) *Scanner {
 			}
 		}
 	} else {
+		if value.mask != nil {
+			scan.pat = newPattern(value)
+		}
 		if bufSize < value.Size() {
 			bufSize = value.Size()
 		} else if bufSize%4 != 0 {
@@ -78,6 +94,16 @@ func NewScannerBuffer(ctx context.Context, value Value, bufSize int) *Scanner {
 	return scan
 }
 
+// NewGroupScanner 搜索 Group, 结果为第一个值的偏移
+func NewGroupScanner(ctx context.Context, group *Group) *Scanner {
+	return &Scanner{
+		ctx:     ctx,
+		value:   group.members[0],
+		bufSize: defScanBufferSize,
+		group:   group,
+	}
+}
+
 func (s *Scanner) ScanCollector(reader io.Reader, collector CollectorFunc, options *Options) {
 	if closer, ok := reader.(io.Closer); ok {
 		defer closer.Close()
@@ -85,7 +111,15 @@ func (s *Scanner) ScanCollector(reader io.Reader, collector CollectorFunc, optio
 	if collector == nil {
 		return
 	}
-	if s.rf32 != nil {
+	if s.multi != nil {
+		s.multi.scan(s, reader, func(offset int, _ Type) bool {
+			return collector(offset)
+		}, options)
+	} else if s.group != nil {
+		s.group.scan(s, reader, collector, options)
+	} else if s.rng != nil {
+		s.rng.scan(s, reader, collector, options)
+	} else if s.rf32 != nil {
 		scanFloat32Rounded(s.ctx, reader, s.bufSize, s.rf32.min, s.rf32.max, collector, options)
 	} else if s.rf64 != nil {
 		scanFloat64Rounded(s.ctx, reader, s.bufSize, s.rf64.min, s.rf64.max, collector, options)
@@ -94,6 +128,24 @@ func (s *Scanner) ScanCollector(reader io.Reader, collector CollectorFunc, optio
 	}
 }
 
+// ScanTypedCollector 仅用于 NewMultiScanner, 其它扫描器的类型为扫描值的类型
+func (s *Scanner) ScanTypedCollector(reader io.Reader, collector TypedCollectorFunc, options *Options) {
+	if s.multi == nil {
+		typ := s.value.Type()
+		s.ScanCollector(reader, func(offset int) bool {
+			return collector(offset, typ)
+		}, options)
+		return
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if collector == nil {
+		return
+	}
+	s.multi.scan(s, reader, collector, options)
+}
+
 func (s *Scanner) scanBytes(reader io.Reader, collector CollectorFunc, options *Options) {
 	var (
 		size = s.value.Size()
@@ -105,10 +157,13 @@ func (s *Scanner) scanBytes(reader io.Reader, collector CollectorFunc, options *
 		forward, backward int
 		offset            int
 		isAligned         = s.value.Aligned()
-		pattern           = s.value.data
 		seeker, _         = reader.(io.Seeker)
 		err               error
 
+		index = func(b []byte) int {
+			return bytes.Index(b, s.value.data)
+		}
+
 		tryAlignNextPage = func() bool {
 			if err != nil && !errors.Is(err, io.EOF) && options != nil {
 				nextOffset, ok := options.alignNextPage(seeker, uint64(offset+backward))
@@ -122,6 +177,10 @@ func (s *Scanner) scanBytes(reader io.Reader, collector CollectorFunc, options *
 		}
 	)
 
+	if s.pat != nil {
+		index = s.pat.Index
+	}
+
 	for {
 		if s.ctx.Err() != nil {
 			return
@@ -152,7 +211,7 @@ func (s *Scanner) scanBytes(reader io.Reader, collector CollectorFunc, options *
 
 		i := 0
 		for {
-			n := bytes.Index(currentChunk[i:], pattern)
+			n := index(currentChunk[i:])
 			if n < 0 {
 				break
 			}
@@ -0,0 +1,167 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package scanner
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding String 类型的文本编码
+type Encoding uint8
+
+const (
+	EncodingUTF8 Encoding = iota
+	EncodingASCII
+	// EncodingUTF16LE Windows (Proton) 程序中的宽字符串
+	EncodingUTF16LE
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingASCII:
+		return "ASCII"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	}
+	return "Unknown"
+}
+
+var (
+	ErrTextEmpty    = errors.New("text must not be empty")
+	ErrTextASCII    = errors.New("text contains non-ASCII characters")
+	ErrTextEncoding = errors.New("unknown text encoding")
+)
+
+// encodeText 按编码转换为字节, ignoreCase 时返回 ASCII 字母忽略大小写的掩码, 否则 mask 为 nil
+// 大小写只差 0x20 一位, 因此忽略大小写可以转换为特征码匹配
+func encodeText(s string, encoding Encoding, ignoreCase bool) (data, mask []byte, err error) {
+	if s == "" {
+		return nil, nil, ErrTextEmpty
+	}
+
+	// 每个字节 (UTF-16LE 为每个码元的低字节) 是否为 ASCII 字母
+	var letters []bool
+	switch encoding {
+	case EncodingASCII:
+		for i := 0; i < len(s); i++ {
+			if s[i] >= utf8.RuneSelf {
+				return nil, nil, ErrTextASCII
+			}
+		}
+		fallthrough
+	case EncodingUTF8:
+		data = []byte(s)
+		letters = make([]bool, len(data))
+		for i, c := range data {
+			letters[i] = isASCIILetter(c)
+		}
+	case EncodingUTF16LE:
+		units := utf16.Encode([]rune(s))
+		data = make([]byte, len(units)*2)
+		letters = make([]bool, len(data))
+		for i, u := range units {
+			byteOrder.PutUint16(data[i*2:], u)
+			letters[i*2] = u < utf8.RuneSelf && isASCIILetter(byte(u))
+		}
+	default:
+		return nil, nil, ErrTextEncoding
+	}
+
+	if !ignoreCase || !slices.Contains(letters, true) {
+		return
+	}
+	mask = make([]byte, len(data))
+	for i := range mask {
+		mask[i] = 0xFF
+		if letters[i] {
+			mask[i] = 0xDF
+		}
+	}
+	return
+}
+
+// decodeText 将内存中的字节解码为可显示的文本, 控制字符和无效字节显示为 '.'
+func decodeText(b []byte, encoding Encoding) string {
+	var sb strings.Builder
+	sb.Grow(len(b))
+
+	write := func(r rune) {
+		if r < 0x20 || r == 0x7F || r == utf8.RuneError {
+			sb.WriteByte('.')
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+
+	switch encoding {
+	case EncodingASCII:
+		for _, c := range b {
+			if c >= utf8.RuneSelf {
+				c = '.'
+			}
+			write(rune(c))
+		}
+	case EncodingUTF16LE:
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			units[i] = byteOrder.Uint16(b[i*2:])
+		}
+		for _, r := range utf16.Decode(units) {
+			write(r)
+		}
+	default:
+		for len(b) > 0 {
+			r, n := utf8.DecodeRune(b)
+			write(r)
+			b = b[n:]
+		}
+	}
+	return sb.String()
+}
+
+func isASCIILetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// NewString 文本搜索, ignoreCase 仅对 ASCII 字母有效
+func NewString(s string, encoding Encoding, ignoreCase bool) (*Value, error) {
+	v := &Value{
+		typ:        String,
+		encoding:   encoding,
+		ignoreCase: ignoreCase,
+	}
+	if err := v.FromString(s); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// NewText 以内存中的原始字节构造 String, 用于显示
+func NewText(b []byte, encoding Encoding) *Value {
+	v := &Value{
+		typ:      String,
+		data:     make([]byte, len(b)),
+		encoding: encoding,
+	}
+	copy(v.data, b)
+	return v
+}
@@ -0,0 +1,86 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+func utf16le(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, len(units)*2)
+	for i, u := range units {
+		byteOrder.PutUint16(b[i*2:], u)
+	}
+	return b
+}
+
+func TestString_Scan(t *testing.T) {
+	utf8Data := []byte("xxPlayerOne..playerone..PLAYERONE..玩家一")
+	utf16Data := append(append([]byte{0}, utf16le("Hero hero HERO")...), utf16le("勇者")...)
+
+	tests := []struct {
+		name       string
+		text       string
+		encoding   Encoding
+		ignoreCase bool
+		data       []byte
+		want       []int
+	}{
+		{"UTF-8", "PlayerOne", EncodingUTF8, false, utf8Data, []int{2}},
+		{"UTF-8 ignore case", "playerONE", EncodingUTF8, true, utf8Data, []int{2, 13, 24}},
+		{"UTF-8 multibyte", "玩家一", EncodingUTF8, false, utf8Data, []int{35}},
+		{"ASCII ignore case", "PLAYER", EncodingASCII, true, utf8Data, []int{2, 13, 24}},
+		{"UTF-16LE", "hero", EncodingUTF16LE, false, utf16Data, []int{11}},
+		{"UTF-16LE ignore case", "HeRo", EncodingUTF16LE, true, utf16Data, []int{1, 11, 21}},
+		{"UTF-16LE non-ASCII", "勇者", EncodingUTF16LE, true, utf16Data, []int{29}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewString(tt.text, tt.encoding, tt.ignoreCase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := NewScannerBuffer(context.TODO(), *v, 8)
+			got := s.testScan(&mockReader{data: tt.data, step: 5})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scan: got %v, want %v", got, tt.want)
+			}
+
+			comp := v.Comparable()
+			for _, i := range tt.want {
+				if !comp.EqualBytes(tt.data[i : i+comp.Size()]) {
+					t.Errorf("EqualBytes at %d: got false", i)
+				}
+			}
+		})
+	}
+}
+
+func TestString_Text(t *testing.T) {
+	if _, err := NewString("玩家", EncodingASCII, false); err != ErrTextASCII {
+		t.Errorf("ASCII: got %v, want %v", err, ErrTextASCII)
+	}
+	if _, err := NewString("", EncodingUTF8, false); err != ErrTextEmpty {
+		t.Errorf("empty: got %v, want %v", err, ErrTextEmpty)
+	}
+
+	tests := []struct {
+		data     []byte
+		encoding Encoding
+		want     string
+	}{
+		{[]byte("Hero\x00\x01"), EncodingUTF8, "Hero.."},
+		{[]byte{'H', 0xE9, 'r'}, EncodingASCII, "H.r"},
+		{[]byte{'H', 0xFF, 'r'}, EncodingUTF8, "H.r"},
+		{utf16le("勇者\x00"), EncodingUTF16LE, "勇者."},
+	}
+	for _, tt := range tests {
+		v := NewText(tt.data, tt.encoding)
+		if got := v.Format(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.encoding, got, tt.want)
+		}
+	}
+}
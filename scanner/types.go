@@ -1,5 +1,11 @@
 package scanner
 
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
 // Type All types are memory aligned, except for bytes
 type Type uint8
 
@@ -11,50 +17,149 @@ const (
 	Int64
 	Float32
 	Float64
+	Uint8
+	Uint16
+	Uint32
+	Uint64
+	// String 文本, 长度和编码取决于输入
+	String
 )
 
-var typeName = [7]string{"Bytes", "Int8", "Int16", "Int32", "Int64", "Float32", "Float64"}
+var typeName = [12]string{"Bytes", "Int8", "Int16", "Int32", "Int64", "Float32", "Float64", "Uint8", "Uint16", "Uint32", "Uint64", "String"}
 
 func (t Type) String() string {
+	if int(t) >= len(typeName) {
+		return "Unknown"
+	}
 	return typeName[t]
 }
 
+// ParseType 按名称 (不区分大小写) 查找类型, 例如 "Int32"
+func ParseType(name string) (Type, bool) {
+	for i, n := range typeName {
+		if strings.EqualFold(n, name) {
+			return Type(i), true
+		}
+	}
+	return Bytes, false
+}
+
+// MarshalText JSON 中使用类型名称
+func (t Type) MarshalText() ([]byte, error) {
+	if int(t) >= len(typeName) {
+		return nil, fmt.Errorf("invalid type: %d", t)
+	}
+	return []byte(typeName[t]), nil
+}
+
+func (t *Type) UnmarshalText(text []byte) error {
+	typ, ok := ParseType(string(text))
+	if !ok {
+		return fmt.Errorf("invalid type: %q", text)
+	}
+	*t = typ
+	return nil
+}
+
 func (t Type) ByteSize() int {
 	switch t {
-	case Int8:
+	case Int8, Uint8:
 		return 1
-	case Int16:
+	case Int16, Uint16:
 		return 2
-	case Int32:
+	case Int32, Uint32:
 		return 4
-	case Int64:
+	case Int64, Uint64:
 		return 8
 	case Float32:
 		return 4
 	case Float64:
 		return 8
 	default:
-		// Bytes, size depends on input length
+		// Bytes, String, size depends on input length
 		return 0
 	}
 }
 
 func (t Type) BitSize() int {
 	switch t {
-	case Int8:
+	case Int8, Uint8:
 		return 8
-	case Int16:
+	case Int16, Uint16:
 		return 16
-	case Int32:
+	case Int32, Uint32:
 		return 32
-	case Int64:
+	case Int64, Uint64:
 		return 64
 	case Float32:
 		return 32
 	case Float64:
 		return 64
 	default:
-		// Bytes
+		// Bytes, String
 		return 0
 	}
 }
+
+// Numeric Whether the type is an integer or float type
+func (t Type) Numeric() bool {
+	return t >= Int8 && t <= Uint64
+}
+
+// Unsigned Whether the type is an unsigned integer type
+func (t Type) Unsigned() bool {
+	return t >= Uint8 && t <= Uint64
+}
+
+// Float Whether the type is a float type
+func (t Type) Float() bool {
+	return t == Float32 || t == Float64
+}
+
+func (t Type) mask() uint64 {
+	switch t.ByteSize() {
+	case 1:
+		return 0xFF
+	case 2:
+		return 0xFFFF
+	case 4:
+		return 0xFFFFFFFF
+	}
+	return math.MaxUint64
+}
+
+func (t Type) signExtend(raw uint64) int64 {
+	switch t.ByteSize() {
+	case 1:
+		return int64(int8(raw))
+	case 2:
+		return int64(int16(raw))
+	case 4:
+		return int64(int32(raw))
+	}
+	return int64(raw)
+}
+
+func (t Type) minInt() int64 {
+	switch t.ByteSize() {
+	case 1:
+		return math.MinInt8
+	case 2:
+		return math.MinInt16
+	case 4:
+		return math.MinInt32
+	}
+	return math.MinInt64
+}
+
+func (t Type) maxInt() int64 {
+	switch t.ByteSize() {
+	case 1:
+		return math.MaxInt8
+	case 2:
+		return math.MaxInt16
+	case 4:
+		return math.MaxInt32
+	}
+	return math.MaxInt64
+}
@@ -3,15 +3,17 @@ package scanner
 import (
 	"bytes"
 	"encoding/binary"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
-	"strings"
 )
 
 var byteOrder = binary.LittleEndian
 
+// ErrValueData UnmarshalBinary 的数据无效
+var ErrValueData = errors.New("invalid value data")
+
 type Option uint8
 
 const (
@@ -43,15 +45,23 @@ func (opt Option) String() string {
 }
 
 type Value struct {
-	typ    Type
-	data   []byte
-	option Option
+	typ     Type
+	data    []byte
+	option  Option
+	compare Compare
+	// CompareBetween 的上限
+	upper []byte
+	// Bytes 特征码的通配掩码, String 忽略大小写的掩码, nil 表示精确匹配
+	mask []byte
+	// String
+	encoding   Encoding
+	ignoreCase bool
 }
 
 // Aligned Whether the memory is aligned
 // when it is a regular type, memory should always be aligned
 func (v *Value) Aligned() bool {
-	return v.typ != Bytes
+	return v.typ != Bytes && v.typ != String
 }
 
 func (v *Value) Type() Type {
@@ -63,6 +73,12 @@ func (v *Value) Option() Option {
 }
 
 func (v *Value) Comparable() ValueComparable {
+	if v.HasCompare() {
+		return newRange(*v)
+	}
+	if v.mask != nil {
+		return newPattern(*v)
+	}
 	if v.HasOption() {
 		switch v.Type() {
 		case Float32:
@@ -79,16 +95,33 @@ func (v *Value) Bytes() []byte {
 }
 
 func (v *Value) String() string {
+	if v.IsPattern() {
+		return formatPattern(v.data, v.mask)
+	}
 	return fmt.Sprintf("% 02X", v.data)
 }
 
+// IsPattern 是否为带通配符的 Bytes 特征码
+func (v *Value) IsPattern() bool {
+	return v.typ == Bytes && v.mask != nil
+}
+
 func (v *Value) Size() int {
-	if v.typ == Bytes {
+	if v.typ == Bytes || v.typ == String {
 		return len(v.data)
 	}
 	return v.typ.ByteSize()
 }
 
+// Clone 深拷贝, 之后修改原值不会影响副本
+func (v *Value) Clone() *Value {
+	c := *v
+	c.data = bytes.Clone(v.data)
+	c.upper = bytes.Clone(v.upper)
+	c.mask = bytes.Clone(v.mask)
+	return &c
+}
+
 func (v *Value) SetBytes(data []byte) {
 	copy(v.data, data)
 }
@@ -97,6 +130,7 @@ func (v *Value) SetType(typ Type) {
 	n := typ.ByteSize()
 	v.typ = typ
 	v.data = make([]byte, n)
+	v.mask = nil
 }
 
 func (v *Value) FromString(s string) (err error) {
@@ -119,6 +153,23 @@ func (v *Value) FromString(s string) (err error) {
 			byteOrder.PutUint64(v.data, uint64(i))
 		default:
 		}
+	case Uint8, Uint16, Uint32, Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(s, 10, bs)
+		if err != nil {
+			return
+		}
+		switch v.typ {
+		case Uint8:
+			v.data[0] = byte(u)
+		case Uint16:
+			byteOrder.PutUint16(v.data, uint16(u))
+		case Uint32:
+			byteOrder.PutUint32(v.data, uint32(u))
+		case Uint64:
+			byteOrder.PutUint64(v.data, u)
+		default:
+		}
 	case Float32, Float64:
 		var i float64
 		i, err = strconv.ParseFloat(s, bs)
@@ -133,11 +184,19 @@ func (v *Value) FromString(s string) (err error) {
 		default:
 		}
 	case Bytes:
-		s = strings.ToLower(strings.Join(strings.Fields(s), ""))
-		if len(s)%2 != 0 {
-			return fmt.Errorf("invalid hex string: %q", s)
+		var data, mask []byte
+		data, mask, err = parsePattern(s)
+		if err != nil {
+			return
+		}
+		v.data, v.mask = data, mask
+	case String:
+		var data, mask []byte
+		data, mask, err = encodeText(s, v.encoding, v.ignoreCase)
+		if err != nil {
+			return
 		}
-		v.data, err = hex.DecodeString(s)
+		v.data, v.mask = data, mask
 	}
 	return
 }
@@ -159,6 +218,14 @@ func (v *Value) ToRaw(typ Type) (i any) {
 		i = int32(byteOrder.Uint32(b))
 	case Int64:
 		i = int64(byteOrder.Uint64(b))
+	case Uint8:
+		i = b[0]
+	case Uint16:
+		i = byteOrder.Uint16(b)
+	case Uint32:
+		i = byteOrder.Uint32(b)
+	case Uint64:
+		i = byteOrder.Uint64(b)
 	case Float32:
 		i = math.Float32frombits(byteOrder.Uint32(v.data))
 	case Float64:
@@ -178,6 +245,9 @@ func (v *Value) Format(args ...Type) string {
 	if typ == Bytes {
 		return v.String()
 	}
+	if typ == String {
+		return v.Text()
+	}
 
 	return fmt.Sprint(v.ToRaw(typ))
 }
@@ -198,6 +268,76 @@ func (v *Value) WithOption(option Option) {
 	}
 }
 
+// Text String 解码后的文本
+func (v *Value) Text() string {
+	return decodeText(v.data, v.encoding)
+}
+
+func (v *Value) Encoding() Encoding {
+	return v.encoding
+}
+
+func (v *Value) IgnoreCase() bool {
+	return v.ignoreCase
+}
+
+// WithEncoding 设置 String 的编码, 需要在 FromString 之前设置
+func (v *Value) WithEncoding(encoding Encoding) {
+	if encoding > EncodingUTF16LE {
+		return
+	}
+	v.encoding = encoding
+}
+
+// WithIgnoreCase 设置 String 是否忽略 ASCII 字母的大小写, 需要在 FromString 之前设置
+func (v *Value) WithIgnoreCase(ignoreCase bool) {
+	v.ignoreCase = ignoreCase
+}
+
+func (v *Value) Compare() Compare {
+	return v.compare
+}
+
+// Upper CompareBetween 的上限, 其它情况返回 nil
+func (v *Value) Upper() *Value {
+	if v.compare != CompareBetween {
+		return nil
+	}
+	upper := &Value{typ: v.typ, data: make([]byte, len(v.upper))}
+	copy(upper.data, v.upper)
+	return upper
+}
+
+// HasCompare 是否为 NotEqual, Greater, Less, Between 比较
+// 此时忽略浮点数的 Option
+func (v *Value) HasCompare() bool {
+	return v.typ.Numeric() && v.compare.Absolute()
+}
+
+// WithCompare 设置 NotEqual, Greater, Less 或 CompareEqual 恢复精确值
+// CompareBetween 请使用 WithBetween
+func (v *Value) WithCompare(compare Compare) {
+	if !v.typ.Numeric() {
+		return
+	}
+	switch compare {
+	case CompareEqual, CompareNotEqual, CompareGreater, CompareLess:
+		v.compare = compare
+		v.upper = nil
+	}
+}
+
+// WithBetween 匹配 v 和 upper 之间的值 (闭区间), upper 的类型必须与 v 相同
+func (v *Value) WithBetween(upper *Value) {
+	if !v.typ.Numeric() || upper == nil || upper.typ != v.typ {
+		return
+	}
+	v.compare = CompareBetween
+	v.upper = make([]byte, len(upper.data))
+	copy(v.upper, upper.data)
+}
+
+// EqualBytes 精确值比较, 比较扫描请使用 Comparable()
 func (v *Value) EqualBytes(b []byte) bool {
 	return bytes.Equal(v.data, b)
 }
@@ -206,6 +346,14 @@ func (v *Value) DisturbingByte() byte {
 	return ^v.data[0]
 }
 
+// float 将浮点类型的值转换为 float64
+func (v *Value) float() float64 {
+	if v.typ == Float32 {
+		return float64(math.Float32frombits(byteOrder.Uint32(v.data)))
+	}
+	return math.Float64frombits(byteOrder.Uint64(v.data))
+}
+
 func (v *Value) isIntegerFloatWithOption() bool {
 	if v.option < OptionFloatRounded || v.option > OptionFloatTruncated {
 		return false
@@ -259,6 +407,42 @@ func NewInt64(i int64) *Value {
 	return v
 }
 
+func NewUint8(i uint8) *Value {
+	v := &Value{
+		typ:  Uint8,
+		data: make([]byte, 1),
+	}
+	v.data[0] = i
+	return v
+}
+
+func NewUint16(i uint16) *Value {
+	v := &Value{
+		typ:  Uint16,
+		data: make([]byte, 2),
+	}
+	byteOrder.PutUint16(v.data, i)
+	return v
+}
+
+func NewUint32(i uint32) *Value {
+	v := &Value{
+		typ:  Uint32,
+		data: make([]byte, 4),
+	}
+	byteOrder.PutUint32(v.data, i)
+	return v
+}
+
+func NewUint64(i uint64) *Value {
+	v := &Value{
+		typ:  Uint64,
+		data: make([]byte, 8),
+	}
+	byteOrder.PutUint64(v.data, i)
+	return v
+}
+
 func NewFloat32(i float32, args ...Option) *Value {
 	var option Option
 	if len(args) > 0 && args[0] <= OptionFloatTruncated {
@@ -301,8 +485,98 @@ func NewBytes(b []byte) *Value {
 	return v
 }
 
+// NewPattern 解析特征码, 例如 "48 8B ?? ?? 05 ?? 00", "4?" 通配低半字节
+func NewPattern(s string) (*Value, error) {
+	v := &Value{typ: Bytes}
+	if err := v.FromString(s); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 type ValueComparable interface {
 	Size() int
+	// EqualBytes 是否匹配, 对于 Range 即是否满足比较条件
 	EqualBytes(b []byte) bool
 	DisturbingByte() byte
 }
+
+// MarshalBinary 用于保存扫描会话
+//
+//	type option compare encoding ignoreCase, data, upper, mask
+//
+// 长度使用 uvarint, mask 的长度加 1, 0 表示 nil
+func (v *Value) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 5+len(v.data)+len(v.upper)+len(v.mask)+3*binary.MaxVarintLen64)
+	var ignoreCase byte
+	if v.ignoreCase {
+		ignoreCase = 1
+	}
+	b = append(b, byte(v.typ), byte(v.option), byte(v.compare), byte(v.encoding), ignoreCase)
+	b = binary.AppendUvarint(b, uint64(len(v.data)))
+	b = append(b, v.data...)
+	b = binary.AppendUvarint(b, uint64(len(v.upper)))
+	b = append(b, v.upper...)
+	if v.mask == nil {
+		b = binary.AppendUvarint(b, 0)
+	} else {
+		b = binary.AppendUvarint(b, uint64(len(v.mask))+1)
+		b = append(b, v.mask...)
+	}
+	return b, nil
+}
+
+func (v *Value) UnmarshalBinary(b []byte) error {
+	if len(b) < 5 {
+		return ErrValueData
+	}
+	c := Value{
+		typ:        Type(b[0]),
+		option:     Option(b[1]),
+		compare:    Compare(b[2]),
+		encoding:   Encoding(b[3]),
+		ignoreCase: b[4] != 0,
+	}
+	b = b[5:]
+	if int(c.typ) >= len(typeName) || c.compare > CompareBetween || c.encoding > EncodingUTF16LE {
+		return ErrValueData
+	}
+
+	field := func(extra uint64) ([]byte, bool) {
+		n, k := binary.Uvarint(b)
+		if k <= 0 {
+			return nil, false
+		}
+		if n == 0 {
+			b = b[k:]
+			return nil, true
+		}
+		if n < extra || n-extra > uint64(len(b)-k) {
+			return nil, false
+		}
+		data := bytes.Clone(b[k : k+int(n-extra)])
+		b = b[k+int(n-extra):]
+		if data == nil {
+			data = []byte{}
+		}
+		return data, true
+	}
+	var ok bool
+	if c.data, ok = field(0); !ok {
+		return ErrValueData
+	}
+	if c.upper, ok = field(0); !ok {
+		return ErrValueData
+	}
+	if c.mask, ok = field(1); !ok || len(b) != 0 {
+		return ErrValueData
+	}
+	if size := c.typ.ByteSize(); size > 0 && (len(c.data) != size || (c.upper != nil && len(c.upper) != size)) {
+		return ErrValueData
+	}
+	if c.mask != nil && len(c.mask) != len(c.data) {
+		return ErrValueData
+	}
+	*v = c
+	return nil
+}
@@ -0,0 +1,345 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+// 会话文件与指针文件相同, gzip 压缩, 整数使用 varint 编码
+//
+//	"MSSN" version
+//	process: pid, startTime, comm
+//	valueType, value
+//	rounds: count, {round, scan, canceled, value, comparison, results, values, types}
+//
+// rounds 按轮次排列, 最后一轮为当前结果, 之前的为撤销历史
+//
+//	value: size, MarshalBinary
+//	canceled: 0 | 1
+//	comparison: 0 | 1 type compare operand
+//	results: count, {address - prevAddress}
+//	values: count, {uint64 little-endian}
+//	types: count, {type}
+const (
+	sessionMagic   = "MSSN"
+	sessionFileVer = 3
+
+	sessionMaxValueSize = 1 << 20
+	sessionMaxCount     = 1 << 32
+	// sessionReadBatch 读取 results, values, types 时每次预留的数量
+	sessionReadBatch = 64 * 1024
+)
+
+var (
+	ErrSessionFile     = errors.New("invalid session file")
+	ErrSessionProcess  = errors.New("session belongs to a different process")
+	ErrSessionSnapshot = errors.New("unknown initial value scan cannot be saved")
+)
+
+// SaveSession 保存当前结果, 撤销历史, 扫描轮数和进程信息
+// value 为当前扫描使用的值 (类型, Option 等), 可以为 nil, 由 LoadSession 返回
+// 未知初始值扫描的快照过大, 不支持保存, 历史中的快照及之前的轮次被忽略
+func (m *Memscan) SaveSession(w io.Writer, value *scanner.Value) error {
+	if m.proc == nil || m.results == nil {
+		return ErrNoProcess
+	}
+	if m.snapshot != nil {
+		return ErrSessionSnapshot
+	}
+
+	rounds := append(m.history[:len(m.history):len(m.history)], m.currentRound())
+	for i := len(rounds) - 1; i >= 0; i-- {
+		if rounds[i].snapshot != nil {
+			rounds = rounds[i+1:]
+			break
+		}
+	}
+
+	return writeGzip(w, func(pw *pointerWriter) {
+		pw.bytes([]byte(sessionMagic))
+		pw.bytes([]byte{sessionFileVer})
+
+		pw.uvarint(uint64(m.proc.PID))
+		pw.uvarint(m.proc.StartTime)
+		pw.string(m.proc.Comm)
+
+		pw.bytes([]byte{byte(m.valueType)})
+		writeValue(pw, value)
+
+		pw.uvarint(uint64(len(rounds)))
+		for _, r := range rounds {
+			pw.uvarint(uint64(r.step.Round))
+			pw.uvarint(uint64(len(r.step.Scan)))
+			pw.bytes([]byte(r.step.Scan))
+			var canceled uint64
+			if r.step.Canceled {
+				canceled = 1
+			}
+			pw.uvarint(canceled)
+			writeValue(pw, r.step.Value)
+			writeComparison(pw, r.step.Comparison)
+
+			writeAddresses(pw, r.results)
+			writeRawValues(pw, r.values)
+			writeTypes(pw, r.types)
+		}
+	})
+}
+
+// LoadSession 恢复 SaveSession 保存的会话, 返回保存时的 value
+// 必须先 Open 同一个进程实例, PID 被复用或者游戏重启后拒绝加载
+// 文件损坏时当前结果被清空
+func (m *Memscan) LoadSession(r io.Reader) (*scanner.Value, error) {
+	if m.maps == nil || m.proc == nil || m.results == nil || !m.proc.Alive() {
+		return nil, ErrNoProcess
+	}
+
+	var value *scanner.Value
+	err := readGzip(r, func(pr *pointerReader) {
+		var header [5]byte
+		if _, pr.err = io.ReadFull(pr.r, header[:]); pr.err != nil {
+			return
+		}
+		if string(header[:4]) != sessionMagic || header[4] != sessionFileVer {
+			pr.fail()
+			return
+		}
+
+		pid := pr.uvarint()
+		startTime := pr.uvarint()
+		_ = pr.string()
+		if pr.err != nil {
+			return
+		}
+		if pid != uint64(m.proc.PID) || startTime != m.proc.StartTime {
+			pr.err = ErrSessionProcess
+			return
+		}
+
+		var valueType [1]byte
+		_, pr.err = io.ReadFull(pr.r, valueType[:])
+		value = readValue(pr)
+		count := pr.uvarint()
+		if pr.err == nil && (count == 0 || count > sessionMaxCount) {
+			pr.fail()
+		}
+		if pr.err != nil {
+			return
+		}
+
+		m.Reset()
+		m.valueType = scanner.Type(valueType[0])
+		for i := uint64(0); i < count && pr.err == nil; i++ {
+			if i > 0 {
+				m.pushRound()
+			}
+			var step HistoryEntry
+			step.Round = uint(pr.uvarint())
+			step.Scan = string(readBytes(pr, sessionMaxValueSize))
+			step.Canceled = pr.uvarint() != 0
+			step.Value = readValue(pr)
+			step.Comparison = readComparison(pr)
+
+			readAddresses(pr, m.results)
+			readRawValues(pr, m.values)
+			readTypes(pr, m.types)
+
+			// 轮次递增, values, types 为空或者与 results 一一对应
+			if pr.err == nil && (step.Round <= m.round || !sessionAligned(m.results, m.values, m.types)) {
+				pr.fail()
+			}
+			m.step = step
+			m.round = step.Round
+		}
+		if pr.err != nil {
+			m.Reset()
+		}
+	})
+	if errors.Is(err, ErrPointerFile) {
+		err = ErrSessionFile
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func writeValue(pw *pointerWriter, value *scanner.Value) {
+	var data []byte
+	if value != nil {
+		data, _ = value.MarshalBinary()
+	}
+	pw.uvarint(uint64(len(data)))
+	pw.bytes(data)
+}
+
+func readValue(pr *pointerReader) *scanner.Value {
+	data := readBytes(pr, sessionMaxValueSize)
+	if len(data) == 0 {
+		return nil
+	}
+	value := &scanner.Value{}
+	if err := value.UnmarshalBinary(data); err != nil {
+		if errors.Is(err, scanner.ErrValueData) {
+			pr.fail()
+		} else {
+			pr.err = err
+		}
+		return nil
+	}
+	return value
+}
+
+func writeComparison(pw *pointerWriter, cmp *scanner.Comparison) {
+	if cmp == nil {
+		pw.bytes([]byte{0})
+		return
+	}
+	pw.bytes([]byte{1, byte(cmp.Type()), byte(cmp.Compare())})
+	writeValue(pw, cmp.Operand())
+}
+
+func readComparison(pr *pointerReader) *scanner.Comparison {
+	var b [1]byte
+	if pr.err != nil {
+		return nil
+	}
+	if _, pr.err = io.ReadFull(pr.r, b[:]); pr.err != nil || b[0] == 0 {
+		return nil
+	}
+	var header [2]byte
+	if _, pr.err = io.ReadFull(pr.r, header[:]); pr.err != nil {
+		return nil
+	}
+	operand := readValue(pr)
+	if pr.err != nil {
+		return nil
+	}
+	cmp, err := scanner.NewComparison(scanner.Type(header[0]), scanner.Compare(header[1]), operand)
+	if err != nil {
+		pr.fail()
+	}
+	return cmp
+}
+
+func sessionAligned(results, values, types *MmapUint64) bool {
+	n := results.Len()
+	return (values.Len() == 0 || values.Len() == n) && (types.Len() == 0 || types.Len() == n)
+}
+
+func bufferData(buf *MmapUint64) []uint64 {
+	if buf == nil {
+		return nil
+	}
+	return buf.Data()
+}
+
+// writeAddresses 结果是有序的, 保存与前一个地址的差
+func writeAddresses(pw *pointerWriter, buf *MmapUint64) {
+	data := bufferData(buf)
+	pw.uvarint(uint64(len(data)))
+	var prev uint64
+	for _, address := range data {
+		pw.uvarint(address - prev)
+		prev = address
+	}
+}
+
+func writeRawValues(pw *pointerWriter, buf *MmapUint64) {
+	data := bufferData(buf)
+	pw.uvarint(uint64(len(data)))
+	var b [8]byte
+	for _, v := range data {
+		binary.LittleEndian.PutUint64(b[:], v)
+		pw.bytes(b[:])
+	}
+}
+
+func writeTypes(pw *pointerWriter, buf *MmapUint64) {
+	data := bufferData(buf)
+	pw.uvarint(uint64(len(data)))
+	for _, typ := range data {
+		pw.uvarint(typ)
+	}
+}
+
+func readBytes(pr *pointerReader, limit int) []byte {
+	n := pr.uvarint()
+	if n > uint64(limit) {
+		pr.fail()
+	}
+	if pr.err != nil || n == 0 {
+		return nil
+	}
+	b := make([]byte, n)
+	_, pr.err = io.ReadFull(pr.r, b)
+	return b
+}
+
+// readCount 读取数量并依次解码每一项
+// 数量来自文件, 按批预留空间, 损坏的数量在数据读完时失败, 不会预先占用大量内存
+func readCount(pr *pointerReader, buf *MmapUint64, next func() uint64) {
+	n := pr.uvarint()
+	if n > sessionMaxCount {
+		pr.fail()
+	}
+	for n > 0 && pr.err == nil {
+		batch := min(n, sessionReadBatch)
+		data, err := buf.Alloc(int(batch))
+		if err != nil {
+			pr.err = err
+			return
+		}
+		for i := range data {
+			data[i] = next()
+		}
+		n -= batch
+	}
+}
+
+func readAddresses(pr *pointerReader, buf *MmapUint64) {
+	var prev uint64
+	readCount(pr, buf, func() uint64 {
+		prev += pr.uvarint()
+		return prev
+	})
+}
+
+func readRawValues(pr *pointerReader, buf *MmapUint64) {
+	var b [8]byte
+	readCount(pr, buf, func() uint64 {
+		if pr.err != nil {
+			return 0
+		}
+		_, pr.err = io.ReadFull(pr.r, b[:])
+		return binary.LittleEndian.Uint64(b[:])
+	})
+}
+
+func readTypes(pr *pointerReader, buf *MmapUint64) {
+	readCount(pr, buf, func() uint64 {
+		typ := pr.uvarint()
+		if typ > uint64(scanner.String) {
+			pr.fail()
+		}
+		return typ
+	})
+}
@@ -0,0 +1,124 @@
+package memscan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/kayon/memscan/deck"
+	"github.com/kayon/memscan/scanner"
+)
+
+func TestMemscan_SaveLoadSession(t *testing.T) {
+	proc, err := deck.NewProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMemscan()
+	if err = m.Open(proc); err != nil {
+		t.Fatal(err)
+	}
+
+	m.SetHistoryLimit(4)
+	value := scanner.NewFloat32(1.5, scanner.OptionFloatRounded)
+	commitTestRound(t, m, value, 0x1000, 0x1004, 0x1008, 0x2000, 0x7FF600001234)
+	operand := scanner.NewFloat32(2.5)
+	cmp, _ := scanner.NewComparison(scanner.Float32, scanner.CompareIncreasedBy, operand)
+	results := []uint64{0x1000, 0x1008, 0x2000, 0x7FF600001234}
+	buf, _ := NewMmapUint64(len(results))
+	_ = buf.Put(results...)
+	m.regionBuffers = []*MmapUint64{buf}
+	m.commitResults(compareStep(cmp))
+	m.step.Canceled = true
+	_ = m.values.Put(1, 2, 3, 1<<63)
+	m.valueType = scanner.Float32
+	history := m.History()
+
+	var w bytes.Buffer
+	if err = m.SaveSession(&w, value); err != nil {
+		t.Fatal(err)
+	}
+	data := w.Bytes()
+
+	m.Reset()
+	loaded, err := m.LoadSession(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == nil || loaded.Type() != scanner.Float32 || loaded.Option() != scanner.OptionFloatRounded || !loaded.EqualBytes(value.Bytes()) {
+		t.Errorf("value: got %v", loaded)
+	}
+	if !slices.Equal(m.Results(), results) || m.values.Len() != 4 {
+		t.Errorf("results: got %X", m.Results())
+	}
+	loadedHistory := m.History()
+	if len(loadedHistory) != 2 || loadedHistory[1].Comparison == nil || m.ValueType() != scanner.Float32 {
+		t.Fatalf("history: got %+v", loadedHistory)
+	}
+	for i := range history {
+		a, b := history[i], loadedHistory[i]
+		if a.Round != b.Round || a.Count != b.Count || a.Scan != b.Scan || a.Canceled != b.Canceled {
+			t.Errorf("history %d: got %+v, want %+v", i, b, a)
+		}
+	}
+	if loadedHistory[1].Scan != "Float32 increased by 2.5" {
+		t.Errorf("comparison: got %q", loadedHistory[1].Scan)
+	}
+	if !m.UndoScan() || m.Count() != 5 || m.Rounds() != 1 {
+		t.Errorf("undo: got %d results", m.Count())
+	}
+
+	// 同一 PID 的另一个进程实例
+	other := *proc
+	other.StartTime++
+	m.proc = &other
+	if _, err = m.LoadSession(bytes.NewReader(data)); !errors.Is(err, ErrSessionProcess) {
+		t.Errorf("expected ErrSessionProcess, got %v", err)
+	}
+	m.proc = proc
+
+	if _, err = m.LoadSession(bytes.NewReader(data[:len(data)/2])); err == nil {
+		t.Error("expected error for truncated session")
+	}
+}
+
+func TestReadAddresses_HugeCount(t *testing.T) {
+	// 数量为 sessionMaxCount, 实际只有两项, 不能按数量预留空间
+	var data []byte
+	data = binary.AppendUvarint(data, sessionMaxCount)
+	data = binary.AppendUvarint(data, 0x1000)
+	data = binary.AppendUvarint(data, 4)
+	pr := &pointerReader{r: bufio.NewReader(bytes.NewReader(data))}
+	buf, err := NewMmapUint64(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Destroy()
+
+	readAddresses(pr, buf)
+	if !errors.Is(pr.err, io.EOF) {
+		t.Fatalf("expected EOF, got %v", pr.err)
+	}
+	if buf.Len() > sessionReadBatch {
+		t.Fatalf("reserved %d items", buf.Len())
+	}
+	if got := buf.Data()[:2]; !slices.Equal(got, []uint64{0x1000, 0x1004}) {
+		t.Fatalf("addresses: got %X", got)
+	}
+}
+
+func TestReadValue_Invalid(t *testing.T) {
+	data := []byte{byte(scanner.Int32), 0, 0, 0, 0, 1, 0xFF, 0, 0}
+	if err := (&scanner.Value{}).UnmarshalBinary(data); !errors.Is(err, scanner.ErrValueData) {
+		t.Fatalf("expected ErrValueData, got %v", err)
+	}
+	pr := &pointerReader{r: bufio.NewReader(bytes.NewReader(append([]byte{byte(len(data))}, data...)))}
+	if value := readValue(pr); value != nil || !errors.Is(pr.err, ErrPointerFile) {
+		t.Fatalf("readValue: got %v, %v", value, pr.err)
+	}
+}
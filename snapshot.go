@@ -0,0 +1,133 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// Snapshot 未知初始值扫描时保存的内存快照
+// 此时结果是快照中所有按 valueSize 对齐的地址, 不再逐个保存
+type Snapshot struct {
+	regions   []*SnapshotRegion
+	valueSize int
+	// source 合并之前的 Region, 由 CaptureSnapshot 设置, 用于 DiffSnapshots 分组
+	source Regions
+}
+
+// SnapshotRegion 一个 Region 的内存副本
+// 读取失败的页面记录在 invalid 中, 不参与比较
+type SnapshotRegion struct {
+	Region
+	data    []byte
+	invalid []uint64
+}
+
+func newSnapshotRegion(region Region) (*SnapshotRegion, error) {
+	data, err := unix.Mmap(-1, 0, int(region.Size), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	pages := (region.Size + memPageSize - 1) / memPageSize
+	sr := &SnapshotRegion{
+		Region:  region,
+		data:    data,
+		invalid: make([]uint64, (pages+63)/64),
+	}
+	// 初始全部无效, 读取成功后再标记
+	for i := range sr.invalid {
+		sr.invalid[i] = ^uint64(0)
+	}
+	runtime.SetFinalizer(sr, func(obj *SnapshotRegion) {
+		obj.destroy()
+	})
+	return sr, nil
+}
+
+func (sr *SnapshotRegion) Data() []byte {
+	return sr.data
+}
+
+// Valid 地址所在页面是否读取成功
+func (sr *SnapshotRegion) Valid(address uint64) bool {
+	if address < sr.Start || address >= sr.End {
+		return false
+	}
+	page := (address - sr.Start) / memPageSize
+	return sr.invalid[page/64]&(1<<(page%64)) == 0
+}
+
+func (sr *SnapshotRegion) markValid(offset, size uint64) {
+	for page := offset / memPageSize; page*memPageSize < offset+size; page++ {
+		sr.invalid[page/64] &^= 1 << (page % 64)
+	}
+}
+
+func (sr *SnapshotRegion) capture(mem MemoryAccessor, buf []byte) (uint64, error) {
+	return readRegion(mem, sr.Start, sr.End, buf, func(addr uint64, data []byte) bool {
+		offset := addr - sr.Start
+		copy(sr.data[offset:], data)
+		sr.markValid(offset, uint64(len(data)))
+		return true
+	})
+}
+
+func (sr *SnapshotRegion) destroy() {
+	if len(sr.data) > 0 {
+		_ = unix.Munmap(sr.data)
+		sr.data = nil
+		runtime.SetFinalizer(sr, nil)
+	}
+}
+
+func (snap *Snapshot) Regions() []*SnapshotRegion {
+	return snap.regions
+}
+
+func (snap *Snapshot) ValueSize() int {
+	return snap.valueSize
+}
+
+// Size 快照占用的字节数
+func (snap *Snapshot) Size() (size uint64) {
+	for _, sr := range snap.regions {
+		size += sr.Size
+	}
+	return
+}
+
+// Count 快照中对齐地址的数量
+func (snap *Snapshot) Count() int {
+	if snap.valueSize <= 0 {
+		return 0
+	}
+	var n uint64
+	for _, sr := range snap.regions {
+		n += sr.Size / uint64(snap.valueSize)
+	}
+	return int(n)
+}
+
+func (snap *Snapshot) Destroy() {
+	for _, sr := range snap.regions {
+		if sr != nil {
+			sr.destroy()
+		}
+	}
+	snap.regions = nil
+}
@@ -0,0 +1,252 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DiffOptions DiffSnapshots 的过滤条件
+// ValueSize 大于 1 时按块比较, 块中任一字节改变则整个块改变, 例如 4 表示可能改变的 Int32/Float32
+// Align 为块地址的对齐, 0 时与 ValueSize 相同
+type DiffOptions struct {
+	ValueSize int
+	Align     int
+}
+
+// DiffRange [Start, End) 两次快照之间改变的字节
+type DiffRange struct {
+	Start uint64
+	End   uint64
+}
+
+func (r DiffRange) Size() uint64 {
+	return r.End - r.Start
+}
+
+// RegionDiff 一个 Region 中改变的范围, 按地址排列
+type RegionDiff struct {
+	Region
+	Ranges []DiffRange
+	// Changed 改变的字节数
+	Changed uint64
+}
+
+// Offset address 相对模块基址 (BaseAddr) 的偏移
+func (d *RegionDiff) Offset(address uint64) uint64 {
+	return address - d.BaseAddr
+}
+
+// CaptureSnapshot 保存 Maps.Parse(level) 选择的 Region 的快照, 不影响扫描结果
+// 两次快照之间进行游戏中的操作, 然后使用 DiffSnapshots 比较, 不再使用时需要 Destroy
+func (m *Memscan) CaptureSnapshot(level RegionScanLevel, args ...bool) (*Snapshot, *ScanReport) {
+	if m.maps == nil || !m.alive() {
+		return nil, errReport(ErrNoProcess)
+	}
+
+	var processPaused bool
+	if len(args) > 0 {
+		processPaused = args[0]
+	}
+
+	if !processPaused && m.proc != nil {
+		m.proc.Pause()
+		defer m.proc.Resume()
+	}
+
+	st := time.Now()
+	source := m.maps.Parse(level)
+	regions := RegionsOptimize(source)
+
+	var total uint64
+	for _, region := range regions {
+		total += region.Size
+	}
+	m.beginProgress(len(regions), total)
+	snap := m.captureSnapshot(regions, 0)
+	snap.source = source
+	m.endProgress()
+
+	return snap, m.progressReport(st)
+}
+
+// DiffSnapshots 比较两个快照中都读取成功的页面, 结果按 Region 分组, 没有改变的 Region 不包含在内
+// 两次快照之间新增或释放的内存不参与比较
+func DiffSnapshots(before, after *Snapshot, opts DiffOptions) []RegionDiff {
+	if before == nil || after == nil {
+		return nil
+	}
+	if opts.ValueSize < 1 {
+		opts.ValueSize = 1
+	}
+	if opts.Align < 1 {
+		opts.Align = opts.ValueSize
+	}
+
+	// 每个 after 中的 Region 一个任务, 与它重叠的 before 中的 Region 依次比较
+	ranges := make([][]DiffRange, len(after.regions))
+	limit := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	k := 0
+	for i, curr := range after.regions {
+		for k < len(before.regions) && before.regions[k].End <= curr.Start {
+			k++
+		}
+		j := k
+		for j < len(before.regions) && before.regions[j].Start < curr.End {
+			j++
+		}
+		if j == k {
+			continue
+		}
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, curr *SnapshotRegion, prevs []*SnapshotRegion) {
+			defer wg.Done()
+			for _, prev := range prevs {
+				ranges[i] = append(ranges[i], diffRegion(prev, curr, opts)...)
+			}
+			<-limit
+		}(i, curr, before.regions[k:j])
+	}
+	wg.Wait()
+
+	source := after.source
+	if source == nil {
+		source = make(Regions, len(after.regions))
+		for i, sr := range after.regions {
+			source[i] = sr.Region
+		}
+	}
+	return groupDiffRanges(source, ranges)
+}
+
+// diffRegion 比较 prev 和 curr 重叠的部分
+func diffRegion(prev, curr *SnapshotRegion, opts DiffOptions) (ranges []DiffRange) {
+	start := max(prev.Start, curr.Start)
+	end := min(prev.End, curr.End)
+
+	for addr := start; addr < end; {
+		pageEnd := min((addr+memPageSize)&^(memPageSize-1), end)
+		if !prev.Valid(addr) || !curr.Valid(addr) {
+			addr = pageEnd
+			continue
+		}
+		a := prev.data[addr-prev.Start : pageEnd-prev.Start]
+		b := curr.data[addr-curr.Start : pageEnd-curr.Start]
+		if !bytes.Equal(a, b) {
+			ranges = diffBytes(ranges, addr, a, b)
+		}
+		addr = pageEnd
+	}
+	if opts.ValueSize > 1 || opts.Align > 1 {
+		ranges = alignDiffRanges(ranges, opts, start, end)
+	}
+	return
+}
+
+// diffBytes 将 a, b 中不同的字节追加到 ranges, 与上一段相邻时合并
+func diffBytes(ranges []DiffRange, base uint64, a, b []byte) []DiffRange {
+	add := func(addr uint64) {
+		if n := len(ranges); n > 0 && ranges[n-1].End == addr {
+			ranges[n-1].End++
+			return
+		}
+		ranges = append(ranges, DiffRange{Start: addr, End: addr + 1})
+	}
+
+	i := 0
+	for ; i+8 <= len(a); i += 8 {
+		if binary.LittleEndian.Uint64(a[i:]) == binary.LittleEndian.Uint64(b[i:]) {
+			continue
+		}
+		for j := i; j < i+8; j++ {
+			if a[j] != b[j] {
+				add(base + uint64(j))
+			}
+		}
+	}
+	for ; i < len(a); i++ {
+		if a[i] != b[i] {
+			add(base + uint64(i))
+		}
+	}
+	return ranges
+}
+
+// alignDiffRanges 扩展为包含改变字节的块 [addr, addr+ValueSize), addr 按 Align 对齐
+// 不超出 [start, end), 重叠或相邻的块合并
+func alignDiffRanges(ranges []DiffRange, opts DiffOptions, start, end uint64) []DiffRange {
+	size, align := uint64(opts.ValueSize), uint64(opts.Align)
+	aligned := make([]DiffRange, 0, len(ranges))
+	add := func(r DiffRange) {
+		if n := len(aligned); n > 0 && aligned[n-1].End >= r.Start {
+			aligned[n-1].End = max(aligned[n-1].End, r.End)
+			return
+		}
+		aligned = append(aligned, r)
+	}
+
+	for _, r := range ranges {
+		// 第一个覆盖 r.Start 的块, 即 addr+size > r.Start
+		var first uint64
+		if r.Start+1 > size {
+			first = r.Start + 1 - size
+		}
+		first = (first + align - 1) / align * align
+		for addr := first; addr < r.End; addr += align {
+			if addr >= start && addr+size <= end {
+				add(DiffRange{Start: addr, End: addr + size})
+			}
+		}
+	}
+	return aligned
+}
+
+// groupDiffRanges ranges 按 after 中的 Region 排列, 拆分到 source 中对应的 Region
+func groupDiffRanges(source Regions, ranges [][]DiffRange) (diffs []RegionDiff) {
+	k := 0
+	var curr *RegionDiff
+	for _, group := range ranges {
+		for _, r := range group {
+			for r.Start < r.End {
+				for k < len(source) && source[k].End <= r.Start {
+					k++
+				}
+				if k == len(source) {
+					return
+				}
+				region := source[k]
+				if region.Start >= r.End {
+					break
+				}
+				part := DiffRange{Start: max(r.Start, region.Start), End: min(r.End, region.End)}
+				if curr == nil || curr.Start != region.Start {
+					diffs = append(diffs, RegionDiff{Region: region})
+					curr = &diffs[len(diffs)-1]
+				}
+				curr.Ranges = append(curr.Ranges, part)
+				curr.Changed += part.Size()
+				r.Start = part.End
+			}
+		}
+	}
+	return
+}
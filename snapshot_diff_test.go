@@ -0,0 +1,93 @@
+package memscan
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	mem := NewFakeMemory("/fake/game")
+	heap := make([]byte, 3*memPageSize)
+	a := make([]byte, memPageSize)
+	b := make([]byte, memPageSize)
+	exe := make([]byte, memPageSize)
+	rw := ParsePermissions("rw-p")
+	for _, err := range []error{
+		mem.Map(0x10000, heap, rw, "[heap]"),
+		// 相邻的小 Region, RegionsOptimize 合并后仍按各自的 Region 分组
+		mem.Map(0x30000, a, rw, ""),
+		mem.Map(0x31000, b, rw, ""),
+		mem.Map(0x40000, exe, rw, "/fake/game"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewMemscan()
+	if err := m.OpenAccessor(mem, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	before, report := m.CaptureSnapshot(REGION_ALL_RW)
+	if report.Err != nil || report.FailedRegions != 0 || report.Matches != 0 {
+		t.Fatalf("capture: %+v", report)
+	}
+	defer before.Destroy()
+
+	heap[0x10], heap[0x11] = 1, 2
+	// 跨页面
+	heap[0x1FFF], heap[0x2000] = 3, 4
+	a[memPageSize-1], b[0] = 5, 6
+	exe[0x101] = 7
+
+	after, _ := m.CaptureSnapshot(REGION_ALL_RW)
+	defer after.Destroy()
+
+	type want struct {
+		start   uint64
+		ranges  []DiffRange
+		changed uint64
+	}
+	check := func(opts DiffOptions, wants []want) {
+		t.Helper()
+		diffs := DiffSnapshots(before, after, opts)
+		if len(diffs) != len(wants) {
+			t.Fatalf("%+v: got %d regions, want %d: %+v", opts, len(diffs), len(wants), diffs)
+		}
+		for i, d := range diffs {
+			if d.Start != wants[i].start || !slices.Equal(d.Ranges, wants[i].ranges) || d.Changed != wants[i].changed {
+				t.Fatalf("%+v: region %d: got %X %X %d, want %+v", opts, i, d.Start, d.Ranges, d.Changed, wants[i])
+			}
+		}
+	}
+
+	check(DiffOptions{}, []want{
+		{0x10000, []DiffRange{{0x10010, 0x10012}, {0x11FFF, 0x12001}}, 4},
+		{0x30000, []DiffRange{{0x30FFF, 0x31000}}, 1},
+		{0x31000, []DiffRange{{0x31000, 0x31001}}, 1},
+		{0x40000, []DiffRange{{0x40101, 0x40102}}, 1},
+	})
+	check(DiffOptions{ValueSize: 4}, []want{
+		{0x10000, []DiffRange{{0x10010, 0x10014}, {0x11FFC, 0x12004}}, 12},
+		{0x30000, []DiffRange{{0x30FFC, 0x31000}}, 4},
+		{0x31000, []DiffRange{{0x31000, 0x31004}}, 4},
+		{0x40000, []DiffRange{{0x40100, 0x40104}}, 4},
+	})
+	// 非对齐的 Int32, 覆盖改变字节的所有候选地址
+	check(DiffOptions{ValueSize: 4, Align: 1}, []want{
+		{0x10000, []DiffRange{{0x1000D, 0x10015}, {0x11FFC, 0x12004}}, 16},
+		{0x30000, []DiffRange{{0x30FFC, 0x31000}}, 4},
+		{0x31000, []DiffRange{{0x31000, 0x31004}}, 4},
+		{0x40000, []DiffRange{{0x400FE, 0x40105}}, 7},
+	})
+
+	diffs := DiffSnapshots(before, after, DiffOptions{})
+	if exeDiff := diffs[3]; exeDiff.Filename != "/fake/game" || exeDiff.Offset(exeDiff.Ranges[0].Start) != 0x101 {
+		t.Fatalf("module offset: %+v", exeDiff)
+	}
+	if m.Count() != 0 || m.Rounds() != 0 {
+		t.Fatal("capture changed the scan results")
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright (C) 2025 kayon <kayon.hu@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memscan
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// pagemap 中每页一项, 见内核文档 admin-guide/mm/pagemap.rst
+	pagemapEntrySize = 8
+	pagemapSoftDirty = 1 << 55
+	pagemapSwapped   = 1 << 62
+	pagemapPresent   = 1 << 63
+
+	// clearRefsSoftDirty 写入 /proc/pid/clear_refs, 只清除 soft-dirty 位
+	clearRefsSoftDirty = "4"
+)
+
+// ErrDirtyTracking 目标不支持 soft-dirty, 例如内存镜像, 或者内核没有 CONFIG_MEM_SOFT_DIRTY
+var ErrDirtyTracking = errors.New("soft-dirty tracking is not supported")
+
+// DirtyPageTracker 可选, 由支持 soft-dirty 的 MemoryAccessor 实现
+type DirtyPageTracker interface {
+	// ClearDirty 清除所有页面的标记, 之后写入的页面重新被标记
+	ClearDirty() error
+	// DirtyPages [start, end) 中每页一位, 1 表示 ClearDirty 之后写入过 (或者无法确定)
+	// start 按页对齐, bits 至少包含 (页数 + 63) / 64 项
+	DirtyPages(start, end uint64, bits []uint64) error
+}
+
+var (
+	_ DirtyPageTracker = procTarget{}
+	_ DirtyPageTracker = (*FakeMemory)(nil)
+)
+
+// softDirtySupported 清除 soft-dirty 在内核不支持时也不会报错, 只能在自身进程中写入一页确认
+var softDirtySupported = sync.OnceValue(func() bool {
+	page, err := unix.Mmap(-1, 0, memPageSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		return false
+	}
+	defer unix.Munmap(page)
+
+	self := procTarget{pid: os.Getpid()}
+	page[0] = 1
+	if self.clearRefs() != nil {
+		return false
+	}
+	page[0] = 2
+	address := uint64(uintptr(unsafe.Pointer(&page[0])))
+	var bits [1]uint64
+	if self.DirtyPages(address, address+memPageSize, bits[:]) != nil {
+		return false
+	}
+	return bits[0]&1 != 0
+})
+
+func (p procTarget) ClearDirty() error {
+	if !softDirtySupported() {
+		return ErrDirtyTracking
+	}
+	return p.clearRefs()
+}
+
+func (p procTarget) clearRefs() error {
+	f, err := os.OpenFile(fmt.Sprintf("/proc/%d/clear_refs", p.pid), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(clearRefsSoftDirty)
+	return err
+}
+
+// DirtyPages 不在内存中也没有换出的页面 (未映射, 或者文件页被回收) 视为写入过, 由读取决定
+// hugetlbfs 的页面不记录 soft-dirty, 其中的写入无法发现
+func (p procTarget) DirtyPages(start, end uint64, bits []uint64) error {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/pagemap", p.pid))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	pages := int((end - start + memPageSize - 1) / memPageSize)
+	buf := make([]byte, min(pages, 4096)*pagemapEntrySize)
+	for page := 0; page < pages; {
+		n := min(pages-page, len(buf)/pagemapEntrySize) * pagemapEntrySize
+		offset := int64((start/memPageSize + uint64(page)) * pagemapEntrySize)
+		if _, err = f.ReadAt(buf[:n], offset); err != nil {
+			return err
+		}
+		for i := 0; i < n; i += pagemapEntrySize {
+			entry := binary.LittleEndian.Uint64(buf[i:])
+			if entry&pagemapSoftDirty != 0 || entry&(pagemapPresent|pagemapSwapped) == 0 {
+				bits[page/64] |= 1 << (page % 64)
+			}
+			page++
+		}
+	}
+	return nil
+}
+
+// dirtyPages [start, end) 的 soft-dirty 位图, 每页一位
+// nil 表示没有记录, 所有页面都视为写入过
+type dirtyPages struct {
+	start uint64
+	end   uint64
+	bits  []uint64
+}
+
+// page address 所在的页面是否写入过
+func (d *dirtyPages) page(address uint64) bool {
+	if d == nil || address < d.start || address >= d.end {
+		return true
+	}
+	i := (address - d.start) / memPageSize
+	return d.bits[i/64]&(1<<(i%64)) != 0
+}
+
+// written [start, end) 不超过 8 字节, 最多跨越两页
+func (d *dirtyPages) written(start, end uint64) bool {
+	return d.page(start) || d.page(end-1)
+}
+
+// run 从 address (按页对齐) 开始状态相同的连续页面, 返回结束地址, 不超过 limit
+func (d *dirtyPages) run(address, limit uint64) (end uint64, written bool) {
+	written = d.page(address)
+	end = address + memPageSize
+	for end < limit && d.page(end) == written {
+		end += memPageSize
+	}
+	return min(end, limit), written
+}
+
+// eachDirtySpan 按 dirty 拆分 region 中的地址
+// 值所在的页面都没有写入过时回调 clean, 它的值与上一轮相同
+// 其余相邻的地址组成子区域回调 written, k 是子区域第一个地址在 region 中的索引
+// 返回 written 读取的字节数之和以及第一个错误, dirty 为 nil 时即是 written(0, region)
+func eachDirtySpan(region *VirtualRegion, size uint64, dirty *dirtyPages, clean func(k int, address uint64), written func(k int, sub *VirtualRegion) (uint64, error)) (read uint64, err error) {
+	if dirty == nil {
+		return written(0, region)
+	}
+	const pageMask = memPageSize - 1
+	for k := 0; k < region.count; {
+		address := region.addresses[k]
+		if !dirty.written(address, address+size) {
+			clean(k, address)
+			k++
+			continue
+		}
+		// 中间隔着整页时拆开, 避免读取没有结果的页面
+		j := k + 1
+		for ; j < region.count; j++ {
+			next := region.addresses[j]
+			last := (region.addresses[j-1] + size - 1) &^ pageMask
+			if !dirty.written(next, next+size) || next&^pageMask > last+memPageSize {
+				break
+			}
+		}
+		sub := &VirtualRegion{
+			Start:     address &^ pageMask,
+			End:       (region.addresses[j-1] + size + pageMask) &^ pageMask,
+			addresses: region.addresses[k:j],
+			index:     region.index + k,
+			count:     j - k,
+		}
+		sub.Size = sub.End - sub.Start
+		n, e := written(k, sub)
+		read += n
+		if err == nil {
+			err = e
+		}
+		k = j
+	}
+	return
+}
+
+// SetDirtyTracking 开启后每轮扫描读取内存之前清除目标的 soft-dirty 标记
+// 下一轮 NextScan, NextScanCompare (dense 以及快照) 只读取其间写入过的页面, 其余页面中的值与上一轮相同
+// 目标不是 DirtyPageTracker 或者内核不支持时返回 ErrDirtyTracking
+// 从下一次扫描开始生效, UndoScan, LoadSession 之后需要再扫描一轮
+// 尚未打开目标时只记录设置, 之后打开的目标不支持时不使用
+func (m *Memscan) SetDirtyTracking(enabled bool) error {
+	m.dirtyValid = false
+	if !enabled || m.mem == nil {
+		m.dirtyTracking = enabled
+		return nil
+	}
+	tracker, ok := m.mem.(DirtyPageTracker)
+	if !ok {
+		return ErrDirtyTracking
+	}
+	if err := tracker.ClearDirty(); err != nil {
+		return err
+	}
+	m.dirtyTracking = true
+	return nil
+}
+
+func (m *Memscan) DirtyTracking() bool {
+	return m.dirtyTracking
+}
+
+func (m *Memscan) dirtyTracker() DirtyPageTracker {
+	if !m.dirtyTracking {
+		return nil
+	}
+	tracker, _ := m.mem.(DirtyPageTracker)
+	return tracker
+}
+
+// restartDirty 在本轮读取内存之前调用, 之后写入的页面都会被标记
+func (m *Memscan) restartDirty() {
+	m.dirtyValid = false
+	if tracker := m.dirtyTracker(); tracker != nil {
+		m.dirtyValid = tracker.ClearDirty() == nil
+	}
+}
+
+// takeDirty 读取上一轮之后写入过的页面, 然后重新开始记录, 在本轮读取内存之前调用
+// 返回值与 n 个 span 一一对应, 没有可用的记录时为 nil, 此时所有页面都需要读取
+func (m *Memscan) takeDirty(n int, span func(i int) (start, end uint64)) []*dirtyPages {
+	dirty := make([]*dirtyPages, n)
+	tracker := m.dirtyTracker()
+	if tracker == nil || !m.dirtyValid {
+		m.restartDirty()
+		return dirty
+	}
+	// 读取和清除之间写入的页面会丢失标记, 这段时间暂停进程
+	if m.proc != nil && m.proc.Refresh() == nil && m.proc.State != 'T' {
+		m.proc.Pause()
+		defer m.proc.Resume()
+	}
+	for i := range dirty {
+		start, end := span(i)
+		start &^= memPageSize - 1
+		pages := (end - start + memPageSize - 1) / memPageSize
+		d := &dirtyPages{start: start, end: end, bits: make([]uint64, (pages+63)/64)}
+		if tracker.DirtyPages(start, end, d.bits) == nil {
+			dirty[i] = d
+		}
+	}
+	m.restartDirty()
+	return dirty
+}
+
+// ClearDirty 之后只有 WriteV 写入的页面被标记, 直接修改 Map 的 data 不会
+func (f *FakeMemory) ClearDirty() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.dead {
+		return unix.ESRCH
+	}
+	f.written = make(map[uint64]struct{})
+	return nil
+}
+
+// DirtyPages 与 procTarget 相同, 未映射的页面视为写入过
+func (f *FakeMemory) DirtyPages(start, end uint64, bits []uint64) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.dead {
+		return unix.ESRCH
+	}
+	for page, addr := 0, start; addr < end; page, addr = page+1, addr+memPageSize {
+		_, written := f.written[addr]
+		if f.written == nil || written || f.find(addr, false) == nil {
+			bits[page/64] |= 1 << (page % 64)
+		}
+	}
+	return nil
+}
+
+// markWritten 在持有写锁时调用
+func (f *FakeMemory) markWritten(start, end uint64) {
+	if f.written == nil {
+		return
+	}
+	for addr := start &^ (memPageSize - 1); addr < end; addr += memPageSize {
+		f.written[addr] = struct{}{}
+	}
+}
@@ -0,0 +1,131 @@
+package memscan
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/kayon/memscan/scanner"
+)
+
+func TestMemscan_DirtyTracking(t *testing.T) {
+	const base = 0x10000
+	mem := NewFakeMemory("/fake/game")
+	heap := make([]byte, 4*memPageSize)
+	addresses := []uint64{base + 0x10, base + memPageSize + 0x20, base + 3*memPageSize + 0x30}
+	for _, address := range addresses {
+		binary.LittleEndian.PutUint32(heap[address-base:], 100)
+	}
+	if err := mem.Map(base, heap, ParsePermissions("rw-p"), ""); err != nil {
+		t.Fatal(err)
+	}
+	// write 经过 WriteV, 页面被标记; 直接修改 heap 不会, 用于确认没有写入过的页面不会被读取
+	write := func(address uint64, v uint32) {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], v)
+		local := []unix.Iovec{{Base: &b[0], Len: 4}}
+		remote := []unix.RemoteIovec{{Base: uintptr(address), Len: 4}}
+		if n, err := mem.WriteV(local, remote); n != 4 {
+			t.Fatalf("write %X: %v", address, err)
+		}
+	}
+	poke := func(address uint64, v uint32) {
+		binary.LittleEndian.PutUint32(heap[address-base:], v)
+	}
+	results := func(m *Memscan) []uint64 {
+		return slices.Sorted(slices.Values(m.Results()))
+	}
+
+	m := NewMemscan()
+	if err := m.OpenAccessor(mem, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.SetDirtyTracking(true); err != nil {
+		t.Fatal(err)
+	}
+
+	if report := m.FirstScan(scanner.NewInt32(100)); report.Matches != 3 {
+		t.Fatalf("first scan: %+v", report)
+	}
+	write(addresses[1], 200)
+	poke(addresses[2], 300)
+	changed, _ := scanner.NewComparison(scanner.Int32, scanner.CompareChanged, nil)
+	report := m.NextScanCompareForceDense(changed)
+	if got := results(m); !slices.Equal(got, addresses[1:2]) {
+		t.Fatalf("changed: got %X, want %X", got, addresses[1:2])
+	}
+	if report.Bytes != memPageSize {
+		t.Fatalf("changed: read %d bytes, want one page", report.Bytes)
+	}
+
+	// 上一轮的值 200 来自读取, 页面之后没有写入
+	poke(addresses[1], 400)
+	if report = m.NextScanForceDense(scanner.NewInt32(200)); report.Matches != 1 || report.Bytes != 0 {
+		t.Fatalf("next scan: %+v", report)
+	}
+
+	// 回到第一轮后没有可用的记录, 重新读取所有页面
+	m.UndoTo(1)
+	if report = m.NextScanForceDense(scanner.NewInt32(100)); report.Matches != 1 {
+		t.Fatalf("after undo: %+v", report)
+	}
+	if got := results(m); !slices.Equal(got, addresses[:1]) {
+		t.Fatalf("after undo: got %X, want %X", got, addresses[:1])
+	}
+
+	// 快照中没有写入过的页面与快照相同
+	if report = m.FirstScanUnknown(scanner.Int32); report.Err != nil {
+		t.Fatal(report.Err)
+	}
+	write(addresses[0], 500)
+	poke(addresses[2], 600)
+	increased, _ := scanner.NewComparison(scanner.Int32, scanner.CompareIncreased, nil)
+	report = m.NextScanCompare(increased)
+	if got := results(m); !slices.Equal(got, addresses[:1]) {
+		t.Fatalf("snapshot increased: got %X, want %X", got, addresses[:1])
+	}
+	if report.Bytes != memPageSize {
+		t.Fatalf("snapshot increased: read %d bytes, want one page", report.Bytes)
+	}
+
+	if err := m.SetDirtyTracking(false); err != nil || m.DirtyTracking() {
+		t.Fatalf("disable: %v", err)
+	}
+}
+
+func TestProcessVM_DirtyPages(t *testing.T) {
+	self := NewProcessVM(os.Getpid())
+	if err := self.ClearDirty(); errors.Is(err, ErrDirtyTracking) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := unix.Mmap(-1, 0, 3*memPageSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Munmap(pages)
+	for i := 0; i < len(pages); i += memPageSize {
+		pages[i] = 1
+	}
+	if err = self.ClearDirty(); err != nil {
+		t.Fatal(err)
+	}
+	pages[memPageSize] = 2
+
+	start := uint64(uintptr(unsafe.Pointer(&pages[0])))
+	var bits [1]uint64
+	if err = self.DirtyPages(start, start+3*memPageSize, bits[:]); err != nil {
+		t.Fatal(err)
+	}
+	if bits[0] != 0b010 {
+		t.Fatalf("dirty pages: got %03b, want 010", bits[0])
+	}
+}
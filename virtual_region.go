@@ -29,12 +29,14 @@ type VirtualRegion struct {
 	End       uint64
 	Size      uint64
 	addresses []uint64
-	count     int
-	cursor    int
+	// addresses[0] 在全部结果中的索引
+	index  int
+	count  int
+	cursor int
 }
 
-func (region *VirtualRegion) Pipe(pid int) io.ReadSeekCloser {
-	return getRegionReader(pid, region.Start, region.End)
+func (region *VirtualRegion) Pipe(mem MemoryAccessor) io.ReadSeekCloser {
+	return getRegionReader(mem, region.Start, region.End)
 }
 
 func (region *VirtualRegion) Match(address uint64) bool {
@@ -82,6 +84,7 @@ func BuildVirtualRegions(addresses []uint64, valueSize uint64) []*VirtualRegion
 				// End: Aligned up to the nearest 4KB page boundary
 				End:       (addresses[i-1] + valueSize + 0xFFF) &^ 0xFFF,
 				addresses: addresses[currentStartIdx:i],
+				index:     currentStartIdx,
 			}
 			region.Size = region.End - region.Start
 			region.count = len(region.addresses)
@@ -97,6 +100,7 @@ func BuildVirtualRegions(addresses []uint64, valueSize uint64) []*VirtualRegion
 		Start:     regionStartAddr,
 		End:       (addresses[len(addresses)-1] + valueSize + 0xFFF) &^ 0xFFF,
 		addresses: addresses[currentStartIdx:],
+		index:     currentStartIdx,
 	}
 	region.Size = region.End - region.Start
 	region.count = len(region.addresses)
@@ -104,3 +108,11 @@ func BuildVirtualRegions(addresses []uint64, valueSize uint64) []*VirtualRegion
 
 	return regions
 }
+
+// virtualRegionsSize 所有 VirtualRegion 的总大小, 用于扫描进度
+func virtualRegionsSize(regions []*VirtualRegion) (size uint64) {
+	for _, region := range regions {
+		size += region.Size
+	}
+	return
+}